@@ -0,0 +1,161 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var (
+	// pushedDigest matches the line docker push ends with, such as
+	// "0.1: digest: sha256:4e38... size: 1573"
+	pushedDigest = regexp.MustCompile(`digest: (sha256:[0-9a-f]{64})`)
+)
+
+const (
+	// CLIBackend builds images by running docker build
+	CLIBackend = "cli"
+
+	// APIBackend builds images by calling the Docker Engine API directly
+	APIBackend = "api"
+)
+
+// ImageBuild is a docker build of a prepared build context
+type ImageBuild struct {
+	// Image is the name and tag given to the built image
+	Image string
+
+	// Tags are more names given to the built image
+	Tags []string
+
+	// ContextPath is the folder sent to the builder, it holds the Dockerfile
+	ContextPath string
+
+	NoCache   bool
+	Squash    bool
+	BuildArgs map[string]string
+
+	// Target is the stage of a multi-stage Dockerfile to build
+	Target string
+
+	// Platform such as linux/arm64 to build for, empty for the platform of
+	// the Docker daemon
+	Platform string
+}
+
+// Backend builds and pushes Docker images
+type Backend interface {
+	// Build builds an image from a build context and returns its ID
+	Build(build ImageBuild) (string, error)
+
+	// Push pushes an image and returns its sha256 digest, registryAuth is the
+	// base64 encoded user:password for backends which do not read the Docker
+	// credentials themselves
+	Push(image string, registryAuth string) (string, error)
+
+	// PushManifestList pushes image as a manifest list of images, which must
+	// already be pushed, and returns the digest of the manifest list. Both
+	// backends write it through the registry API with registryAuth.
+	PushManifestList(image string, images []PlatformImage, registryAuth string) (string, error)
+
+	// ImageExists is true when image is in the local image store
+	ImageExists(image string) (bool, error)
+
+	// Tag gives the local image source the name target
+	Tag(source string, target string) error
+}
+
+// NewBackend returns the backend for a --builder name, the Engine API
+// backend connects to DOCKER_HOST or to the local socket when it is unset
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case CLIBackend, "":
+		return &cliBackend{}, nil
+	case APIBackend:
+		return NewEngineBackend(os.Getenv("DOCKER_HOST"))
+	default:
+		return nil, fmt.Errorf("unknown builder %q, use %s or %s", name, CLIBackend, APIBackend)
+	}
+}
+
+// cliBackend runs docker build in the build context
+type cliBackend struct{}
+
+func (b *cliBackend) Build(build ImageBuild) (string, error) {
+	// docker build writes the image ID to --iidfile
+	idFile, err := ioutil.TempFile("", "faas-cli-iid")
+	if err != nil {
+		return "", err
+	}
+	idFile.Close()
+	defer os.Remove(idFile.Name())
+
+	flagSlice := buildFlagSlice(build.NoCache, build.Squash, build.BuildArgs, build.Target)
+
+	spaceSafeCmdLine := []string{"docker", "build"}
+	spaceSafeCmdLine = append(spaceSafeCmdLine, flagSlice...)
+	if len(build.Platform) > 0 {
+		spaceSafeCmdLine = append(spaceSafeCmdLine, "--platform", build.Platform)
+	}
+	spaceSafeCmdLine = append(spaceSafeCmdLine, "--iidfile", idFile.Name(), "-t", build.Image)
+	for _, tag := range build.Tags {
+		spaceSafeCmdLine = append(spaceSafeCmdLine, "-t", tag)
+	}
+	spaceSafeCmdLine = append(spaceSafeCmdLine, ".")
+
+	if err := RunCommand(build.ContextPath, spaceSafeCmdLine); err != nil {
+		return "", err
+	}
+
+	imageID, err := ioutil.ReadFile(idFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("cannot read the ID of %s: %s", build.Image, err)
+	}
+	return strings.TrimSpace(string(imageID)), nil
+}
+
+// Push runs docker push, which reads the credentials saved by docker login,
+// and returns the digest it prints once the image is pushed
+func (b *cliBackend) Push(image string, registryAuth string) (string, error) {
+	output, err := RunCommandOutput("./", []string{"docker", "push", image})
+	if err != nil {
+		return "", err
+	}
+
+	match := pushedDigest.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("push of %s did not report a digest", image)
+	}
+	return match[1], nil
+}
+
+// PushManifestList writes the manifest list through the registry API, as
+// the docker CLI can only push one when its experimental features are on
+func (b *cliBackend) PushManifestList(image string, images []PlatformImage, registryAuth string) (string, error) {
+	return pushManifestList(http.DefaultClient, image, images, registryAuth)
+}
+
+// ImageExists runs docker image inspect, which fails with "No such image"
+// when image is not in the local image store
+func (b *cliBackend) ImageExists(image string) (bool, error) {
+	output, err := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", image).CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(strings.ToLower(string(output)), "no such image") {
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot inspect %s: %s", image, strings.TrimSpace(string(output)))
+}
+
+// Tag runs docker tag
+func (b *cliBackend) Tag(source string, target string) error {
+	return RunCommand("./", []string{"docker", "tag", source, target})
+}
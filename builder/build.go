@@ -5,7 +5,6 @@ package builder
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
@@ -16,69 +15,103 @@ import (
 // Can also be passed as a build arg hence needs to be accessed from commands
 const AdditionalPackageBuildArg = "ADDITIONAL_PACKAGE"
 
-// BuildImage construct Docker image from function parameters
-func BuildImage(image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string) {
+// FunctionBuild is the build of a function's image, BuildImage fills in the
+// context path and adds the proxy and build option packages to the build args
+type FunctionBuild struct {
+	ImageBuild
 
-	if stack.IsValidTemplate(language) {
+	// FunctionName names the build folder ./build/<function name>/
+	FunctionName string
 
-		var tempPath string
+	Handler  string
+	Language string
 
-		if strings.ToLower(language) == "dockerfile" {
+	// BuildOptions are the names of build options such as dev, whose
+	// packages are installed in the image
+	BuildOptions []string
 
-			if shrinkwrap {
-				fmt.Printf("Nothing to do for: %s.\n", functionName)
+	// Shrinkwrap writes the build folder without building the image
+	Shrinkwrap bool
+}
 
-				return
-			}
+// BuildImage construct Docker image from function parameters using backend,
+// the image is also given each of its tags. An empty platform builds for the
+// platform of the Docker daemon. The image ID is returned, which is empty
+// when shrinkwrap is set.
+func BuildImage(backend Backend, function FunctionBuild) (string, error) {
+	image := function.Image
+	handler := function.Handler
+	functionName := function.FunctionName
+	language := function.Language
+	shrinkwrap := function.Shrinkwrap
+
+	if !stack.IsValidTemplate(language) {
+		return "", fmt.Errorf("language template: %s not supported, build a custom Dockerfile instead", language)
+	}
 
-			tempPath = handler
-			if err := ensureHandlerPath(handler); err != nil {
-				fmt.Printf("Unable to build %s, %s is an invalid path\n", image, handler)
-				fmt.Printf("Image: %s not built.\n", image)
+	var tempPath string
 
-				return
-			}
-			fmt.Printf("Building: %s with Dockerfile. Please wait..\n", image)
+	if strings.ToLower(language) == "dockerfile" {
 
-		} else {
+		if shrinkwrap {
+			fmt.Printf("Nothing to do for: %s.\n", functionName)
 
-			if err := ensureHandlerPath(handler); err != nil {
-				fmt.Printf("Unable to build %s, %s is an invalid path\n", image, handler)
-				fmt.Printf("Image: %s not built.\n", image)
+			return "", nil
+		}
 
-				return
-			}
-			tempPath = createBuildTemplate(functionName, handler, language)
-			fmt.Printf("Building: %s with %s template. Please wait..\n", image, language)
+		tempPath = handler
+		if err := ensureHandlerPath(handler); err != nil {
+			return "", fmt.Errorf("unable to build %s, %s is an invalid path", image, handler)
+		}
+		fmt.Printf("Building: %s with Dockerfile. Please wait..\n", image)
 
-			if shrinkwrap {
-				fmt.Printf("%s shrink-wrapped to %s\n", functionName, tempPath)
+	} else {
 
-				return
-			}
+		if err := ensureHandlerPath(handler); err != nil {
+			return "", fmt.Errorf("unable to build %s, %s is an invalid path", image, handler)
 		}
 
-		buildOptPackages, bopErr := getBuildOptionPackages(buildOptions, language)
+		var excluded []string
+		var err error
+		tempPath, excluded, err = createBuildTemplate(functionName, handler, language)
+		if err != nil {
+			return "", fmt.Errorf("unable to build %s, %s", image, err)
+		}
+		fmt.Printf("Building: %s with %s template. Please wait..\n", image, language)
+
+		if shrinkwrap {
+			fmt.Printf("%s shrink-wrapped to %s\n", functionName, tempPath)
+			if len(excluded) > 0 {
+				fmt.Printf("Left out by %s:\n", strings.Join(IgnoreFiles, " and "))
+				for _, path := range excluded {
+					fmt.Printf("- %s\n", path)
+				}
+			}
 
-		if bopErr != nil {
-			fmt.Println(bopErr)
-			return
+			return "", nil
 		}
+	}
 
-		flagSlice := buildFlagSlice(nocache, squash, os.Getenv("http_proxy"), os.Getenv("https_proxy"), buildArgMap, buildOptPackages)
-		spaceSafeCmdLine := []string{"docker", "build"}
-		spaceSafeCmdLine = append(spaceSafeCmdLine, flagSlice...)
-		spaceSafeCmdLine = append(spaceSafeCmdLine, "-t", image, ".")
-		ExecCommand(tempPath, spaceSafeCmdLine)
-		fmt.Printf("Image: %s built.\n", image)
+	buildOptPackages, err := getBuildOptionPackages(function.BuildOptions, language)
+	if err != nil {
+		return "", err
+	}
 
-	} else {
-		log.Fatalf("Language template: %s not supported. Build a custom Dockerfile instead.", language)
+	build := function.ImageBuild
+	build.ContextPath = tempPath
+	build.BuildArgs = imageBuildArgs(os.Getenv("http_proxy"), os.Getenv("https_proxy"), function.BuildArgs, buildOptPackages)
+	imageID, err := backend.Build(build)
+	if err != nil {
+		return "", err
 	}
+
+	fmt.Printf("Image: %s built.\n", image)
+	return imageID, nil
 }
 
-// createBuildTemplate creates temporary build folder to perform a Docker build with language template
-func createBuildTemplate(functionName string, handler string, language string) string {
+// createBuildTemplate creates temporary build folder to perform a Docker build with language template,
+// the paths left out by ignore files are returned relative to the build folder
+func createBuildTemplate(functionName string, handler string, language string) (string, []string, error) {
 	tempPath := fmt.Sprintf("./build/%s/", functionName)
 	fmt.Printf("Clearing temporary build folder: %s\n", tempPath)
 
@@ -99,44 +132,66 @@ func createBuildTemplate(functionName string, handler string, language string) s
 	if language == "Dockerfile" {
 		language = "dockerfile"
 	}
-	CopyFiles("./template/"+language, tempPath)
+	excluded, err := CopyFilesIgnoring("./template/"+language, tempPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error copying template %s: %s", language, err)
+	}
 
 	// Overlay in user-function
-	CopyFiles(handler, functionPath)
+	excludedFunction, err := CopyFilesIgnoring(handler, functionPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error copying handler %s: %s", handler, err)
+	}
+	for _, path := range excludedFunction {
+		excluded = append(excluded, "function/"+path)
+	}
 
-	return tempPath
+	return tempPath, excluded, nil
 }
 
-func buildFlagSlice(nocache bool, squash bool, httpProxy string, httpsProxy string, buildArgMap map[string]string, buildOptionPackages []string) []string {
-
-	var spaceSafeBuildFlags []string
-
-	if nocache {
-		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--no-cache")
-	}
-	if squash {
-		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--squash")
-	}
+// imageBuildArgs returns the build-args for an image build, with the proxy
+// settings and the packages from build options added to buildArgMap
+func imageBuildArgs(httpProxy string, httpsProxy string, buildArgMap map[string]string, buildOptionPackages []string) map[string]string {
+	buildArgs := map[string]string{}
 
 	if len(httpProxy) > 0 {
-		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--build-arg", fmt.Sprintf("http_proxy=%s", httpProxy))
+		buildArgs["http_proxy"] = httpProxy
 	}
-
 	if len(httpsProxy) > 0 {
-		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--build-arg", fmt.Sprintf("https_proxy=%s", httpsProxy))
+		buildArgs["https_proxy"] = httpsProxy
 	}
 
 	for k, v := range buildArgMap {
-
 		if k != AdditionalPackageBuildArg {
-			spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+			buildArgs[k] = v
 		} else {
 			buildOptionPackages = append(buildOptionPackages, strings.Split(v, " ")...)
 		}
 	}
 	if len(buildOptionPackages) > 0 {
-		buildOptionPackages = deDuplicate(buildOptionPackages)
-		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--build-arg", fmt.Sprintf("%s=%s", AdditionalPackageBuildArg, strings.Join(buildOptionPackages, " ")))
+		buildArgs[AdditionalPackageBuildArg] = strings.Join(deDuplicate(buildOptionPackages), " ")
+	}
+
+	return buildArgs
+}
+
+func buildFlagSlice(nocache bool, squash bool, buildArgMap map[string]string, buildTarget string) []string {
+
+	var spaceSafeBuildFlags []string
+
+	if nocache {
+		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--no-cache")
+	}
+	if squash {
+		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--squash")
+	}
+
+	for k, v := range buildArgMap {
+		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	if len(buildTarget) > 0 {
+		spaceSafeBuildFlags = append(spaceSafeBuildFlags, "--target", buildTarget)
 	}
 
 	return spaceSafeBuildFlags
@@ -13,129 +13,79 @@ func Test_buildFlagSlice(t *testing.T) {
 		title         string
 		nocache       bool
 		squash        bool
-		httpProxy     string
-		httpsProxy    string
 		buildArgMap   map[string]string
-		buildPackages []string
+		buildTarget   string
 		expectedSlice []string
 	}{
 		{
 			title:         "no cache only",
 			nocache:       true,
 			squash:        false,
-			httpProxy:     "",
-			httpsProxy:    "",
 			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
 			expectedSlice: []string{"--no-cache"},
 		},
 		{
 			title:         "no cache & squash only",
 			nocache:       true,
 			squash:        true,
-			httpProxy:     "",
-			httpsProxy:    "",
 			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
 			expectedSlice: []string{"--no-cache", "--squash"},
 		},
 		{
-			title:         "no cache & squash & http proxy only",
-			nocache:       true,
-			squash:        true,
-			httpProxy:     "192.168.0.1",
-			httpsProxy:    "",
-			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
-			expectedSlice: []string{"--no-cache", "--squash", "--build-arg", "http_proxy=192.168.0.1"},
-		},
-		{
-			title:         "no cache & squash & https-proxy only",
-			nocache:       true,
-			squash:        true,
-			httpProxy:     "",
-			httpsProxy:    "127.0.0.1",
-			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
-			expectedSlice: []string{"--no-cache", "--squash", "--build-arg", "https_proxy=127.0.0.1"},
-		},
-		{
-			title:         "no cache & squash & http-proxy & https-proxy only",
-			nocache:       true,
-			squash:        true,
-			httpProxy:     "192.168.0.1",
-			httpsProxy:    "127.0.0.1",
-			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
-			expectedSlice: []string{"--no-cache", "--squash", "--build-arg", "http_proxy=192.168.0.1", "--build-arg", "https_proxy=127.0.0.1"},
-		},
-		{
-			title:         "http-proxy & https-proxy only",
-			nocache:       false,
-			squash:        false,
-			httpProxy:     "192.168.0.1",
-			httpsProxy:    "127.0.0.1",
-			buildArgMap:   make(map[string]string),
-			buildPackages: []string{},
-			expectedSlice: []string{"--build-arg", "http_proxy=192.168.0.1", "--build-arg", "https_proxy=127.0.0.1"},
-		},
-		{
-			title:      "build arg map no spaces",
-			nocache:    false,
-			squash:     false,
-			httpProxy:  "",
-			httpsProxy: "",
+			title:   "build arg map no spaces",
+			nocache: false,
+			squash:  false,
 			buildArgMap: map[string]string{
 				"muppet": "ernie",
 			},
-			buildPackages: []string{},
 			expectedSlice: []string{"--build-arg", "muppet=ernie"},
 		},
 		{
-			title:      "build arg map with spaces",
-			nocache:    false,
-			squash:     false,
-			httpProxy:  "",
-			httpsProxy: "",
+			title:   "build arg map with spaces",
+			nocache: false,
+			squash:  false,
 			buildArgMap: map[string]string{
 				"muppets": "burt and ernie",
 			},
-			buildPackages: []string{},
 			expectedSlice: []string{"--build-arg", "muppets=burt and ernie"},
 		},
 		{
-			title:      "multiple build arg map with spaces",
-			nocache:    false,
-			squash:     false,
-			httpProxy:  "",
-			httpsProxy: "",
+			title:   "multiple build arg map with spaces",
+			nocache: false,
+			squash:  false,
 			buildArgMap: map[string]string{
 				"muppets":    "burt and ernie",
 				"playschool": "Jemima",
 			},
-			buildPackages: []string{},
 			expectedSlice: []string{"--build-arg", "muppets=burt and ernie", "--build-arg", "playschool=Jemima"},
 		},
 		{
-			title:      "no-cache and squash with multiple build arg map with spaces",
-			nocache:    true,
-			squash:     true,
-			httpProxy:  "",
-			httpsProxy: "",
+			title:   "no-cache and squash with multiple build arg map with spaces",
+			nocache: true,
+			squash:  true,
 			buildArgMap: map[string]string{
 				"muppets":    "burt and ernie",
 				"playschool": "Jemima",
 			},
-			buildPackages: []string{},
 			expectedSlice: []string{"--no-cache", "--squash", "--build-arg", "muppets=burt and ernie", "--build-arg", "playschool=Jemima"},
 		},
+		{
+			title:   "build target with build arg",
+			nocache: false,
+			squash:  false,
+			buildArgMap: map[string]string{
+				"GO_VERSION": "1.10",
+			},
+			buildTarget:   "release",
+			expectedSlice: []string{"--build-arg", "GO_VERSION=1.10", "--target", "release"},
+		},
 	}
 
 	for _, test := range buildFlagOpts {
 
 		t.Run(test.title, func(t *testing.T) {
 
-			flagSlice := buildFlagSlice(test.nocache, test.squash, test.httpProxy, test.httpsProxy, test.buildArgMap, test.buildPackages)
+			flagSlice := buildFlagSlice(test.nocache, test.squash, test.buildArgMap, test.buildTarget)
 
 			if len(flagSlice) != len(test.expectedSlice) {
 				t.Errorf("Slices differ in size - wanted: %d, found %d", len(test.expectedSlice), len(flagSlice))
@@ -157,6 +107,56 @@ func Test_buildFlagSlice(t *testing.T) {
 
 }
 
+func Test_imageBuildArgs(t *testing.T) {
+
+	var buildArgOpts = []struct {
+		title         string
+		httpProxy     string
+		httpsProxy    string
+		buildArgMap   map[string]string
+		buildPackages []string
+		expectedArgs  map[string]string
+	}{
+		{
+			title:        "http proxy only",
+			httpProxy:    "192.168.0.1",
+			buildArgMap:  make(map[string]string),
+			expectedArgs: map[string]string{"http_proxy": "192.168.0.1"},
+		},
+		{
+			title:        "http-proxy & https-proxy with build arg",
+			httpProxy:    "192.168.0.1",
+			httpsProxy:   "127.0.0.1",
+			buildArgMap:  map[string]string{"muppet": "ernie"},
+			expectedArgs: map[string]string{"http_proxy": "192.168.0.1", "https_proxy": "127.0.0.1", "muppet": "ernie"},
+		},
+		{
+			title:         "packages from build options and build args",
+			buildArgMap:   map[string]string{AdditionalPackageBuildArg: "jq curl"},
+			buildPackages: []string{"make", "jq"},
+			expectedArgs:  map[string]string{AdditionalPackageBuildArg: "make jq curl"},
+		},
+	}
+
+	for _, test := range buildArgOpts {
+
+		t.Run(test.title, func(t *testing.T) {
+
+			buildArgs := imageBuildArgs(test.httpProxy, test.httpsProxy, test.buildArgMap, test.buildPackages)
+
+			if len(buildArgs) != len(test.expectedArgs) {
+				t.Errorf("Build args differ in size - wanted: %v, found %v", test.expectedArgs, buildArgs)
+			}
+			for k, v := range test.expectedArgs {
+				if buildArgs[k] != v {
+					t.Errorf("Build arg %s differs - wanted: %q, found %q", k, v, buildArgs[k])
+				}
+			}
+		})
+	}
+
+}
+
 func Test_getPackages(t *testing.T) {
 	var buildOpts = []struct {
 		title                 string
@@ -7,17 +7,61 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // CopyFiles copies files from src to destination.
 func CopyFiles(src, dest string) error {
-	info, err := os.Stat(src)
+	c := &copier{root: src}
+	return c.copy(src, dest)
+}
+
+// CopyFilesIgnoring copies files from src to dest leaving out the paths
+// matched by the .dockerignore and .faasignore files in src. The paths left
+// out are returned relative to src. A folder which is left out is not read,
+// so "!" patterns cannot bring back files inside it.
+func CopyFilesIgnoring(src, dest string) ([]string, error) {
+	ignore, err := readIgnoreFiles(src, IgnoreFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &copier{root: src, ignore: ignore}
+	if err := c.copy(src, dest); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(c.excluded)
+	return c.excluded, nil
+}
+
+// copier copies a folder, symlinks are copied as symlinks and must stay
+// inside root
+type copier struct {
+	root     string
+	ignore   *ignoreMatcher
+	excluded []string
+}
+
+func (c *copier) copy(src, dest string) error {
+	if relative, err := filepath.Rel(c.root, src); err == nil && c.ignore.Matches(relative) {
+		c.excluded = append(c.excluded, filepath.ToSlash(relative))
+		return nil
+	}
+
+	info, err := os.Lstat(src)
 	if err != nil {
 		return err
 	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return c.copySymlink(src, dest)
+	}
+
 	if info.IsDir() {
 		debugPrint(fmt.Sprintf("Creating directory: %s at %s", info.Name(), dest))
-		return copyDir(src, dest)
+		return c.copyDir(src, dest)
 	}
 
 	debugPrint(fmt.Sprintf("cp - %s %s", src, dest))
@@ -25,7 +69,7 @@ func CopyFiles(src, dest string) error {
 }
 
 // copyDir will recursively copy a directory to dest
-func copyDir(src, dest string) error {
+func (c *copier) copyDir(src, dest string) error {
 	info, err := os.Stat(src)
 	if err != nil {
 		return fmt.Errorf("error reading dest stats: %s", err.Error())
@@ -41,7 +85,7 @@ func copyDir(src, dest string) error {
 	}
 
 	for _, info := range infos {
-		if err := CopyFiles(
+		if err := c.copy(
 			filepath.Join(src, info.Name()),
 			filepath.Join(dest, info.Name()),
 		); err != nil {
@@ -52,6 +96,37 @@ func copyDir(src, dest string) error {
 	return nil
 }
 
+// copySymlink recreates a symlink, which must point inside root
+func (c *copier) copySymlink(src, dest string) error {
+	target, err := readInsideSymlink(c.root, src)
+	if err != nil {
+		return err
+	}
+
+	debugPrint(fmt.Sprintf("ln -s %s %s", target, dest))
+	return os.Symlink(target, dest)
+}
+
+// readInsideSymlink returns the target of a symlink under root. A symlink
+// which is absolute or points outside root is an error, as following it
+// could copy any file on the machine into the build context, past the
+// ignore files of root.
+func readInsideSymlink(root string, path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+
+	resolved := filepath.Join(filepath.Dir(path), target)
+	relative, err := filepath.Rel(root, resolved)
+	if filepath.IsAbs(target) || err != nil ||
+		relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("symlink %s points outside %s to %s, copy what it points to into the folder or leave it out with %s",
+			path, root, target, strings.Join(IgnoreFiles, " or "))
+	}
+	return target, nil
+}
+
 // copyFile will copy a file with the same mode as the src file
 func copyFile(src, dest string) error {
 	info, err := os.Stat(src)
@@ -77,7 +152,7 @@ func copyFile(src, dest string) error {
 
 	_, err = io.Copy(f, s)
 	if err != nil {
-		return fmt.Errorf("Error copying dest file: %s\n" + err.Error())
+		return fmt.Errorf("error copying dest file: %s", err.Error())
 	}
 
 	return nil
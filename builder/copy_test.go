@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -72,3 +74,102 @@ func checkDestinationFiles(dir string, numberOfFiles, mode int) error {
 
 	return nil
 }
+
+func Test_CopyFilesIgnoring(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "openfaas-test-source-")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	outsideDir, err := ioutil.TempDir("", "openfaas-test-outside-")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(outsideDir)
+
+	destDir, err := ioutil.TempDir("", "openfaas-test-destination-")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(destDir)
+
+	files := map[string]string{
+		".dockerignore":                 "node_modules\n*.md\n!README.md\n",
+		".faasignore":                   "test/\n",
+		"handler.js":                    "module.exports = () => {}\n",
+		"README.md":                     "# figlet\n",
+		"NOTES.md":                      "todo\n",
+		"node_modules/express/index.js": "express\n",
+		"test/handler.test.js":          "test\n",
+		"lib/util.js":                   "util\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(outsideDir, "shared.js"), []byte("shared\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	// An inside symlink is kept, an outside one which is ignored is left out
+	if err := os.Symlink("lib/util.js", filepath.Join(srcDir, "util.js")); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(srcDir, "test", "shared")); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	excluded, err := CopyFilesIgnoring(srcDir, destDir)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if got := strings.Join(excluded, ","); got != "NOTES.md,node_modules,test" {
+		t.Errorf("want excluded paths NOTES.md,node_modules,test, got %s", got)
+	}
+
+	for _, name := range []string{"handler.js", "README.md", "lib/util.js"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("want %s copied: %s", name, err)
+		}
+	}
+	for _, name := range []string{"NOTES.md", "node_modules", "test"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err == nil {
+			t.Errorf("want %s left out", name)
+		}
+	}
+
+	if link, err := os.Readlink(filepath.Join(destDir, "util.js")); err != nil || link != "lib/util.js" {
+		t.Errorf("want util.js copied as a symlink to lib/util.js, got %q %v", link, err)
+	}
+}
+
+func Test_CopyFilesIgnoring_OutsideSymlink(t *testing.T) {
+	for _, target := range []string{"/does/not/exist", "/etc", "..", "../../etc/passwd", "lib/../../outside"} {
+		srcDir, err := ioutil.TempDir("", "openfaas-test-source-")
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		defer os.RemoveAll(srcDir)
+
+		destDir, err := ioutil.TempDir("", "openfaas-test-destination-")
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		defer os.RemoveAll(destDir)
+
+		if err := os.Symlink(target, filepath.Join(srcDir, "link")); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+
+		if _, err := CopyFilesIgnoring(srcDir, destDir); err == nil || !strings.Contains(err.Error(), "points outside") {
+			t.Errorf("want an error for a symlink to %s, got %v", target, err)
+		}
+	}
+}
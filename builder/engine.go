@@ -0,0 +1,333 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDockerHost is the socket of a local Docker daemon
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// EngineBackend builds images through the Docker Engine API, streaming the
+// build context as a tar archive so the docker CLI is not needed
+type EngineBackend struct {
+	// baseURL is the HTTP address of the API, for a unix socket the host is
+	// ignored and every request is dialled to the socket
+	baseURL string
+	client  *http.Client
+}
+
+// NewEngineBackend connects to a Docker host such as
+// unix:///var/run/docker.sock or tcp://127.0.0.1:2375, an empty host is the
+// local socket. TLS connections to the daemon are not supported.
+func NewEngineBackend(host string) (*EngineBackend, error) {
+	if len(host) == 0 {
+		host = DefaultDockerHost
+	}
+
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Docker host %q: %s", host, err)
+	}
+
+	switch hostURL.Scheme {
+	case "unix":
+		socket := hostURL.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &EngineBackend{baseURL: "http://docker", client: &http.Client{Transport: transport}}, nil
+	case "tcp", "http":
+		return &EngineBackend{baseURL: "http://" + hostURL.Host, client: http.DefaultClient}, nil
+	default:
+		return nil, fmt.Errorf("unsupported Docker host %q, use a unix:// or tcp:// address", host)
+	}
+}
+
+// jsonMessage is one line of the JSON stream written by the build and push
+// endpoints
+type jsonMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	Aux struct {
+		ID     string `json:"ID"`
+		Digest string `json:"Digest"`
+	} `json:"aux"`
+}
+
+// Build sends the build context to the daemon and prints the build output,
+// an error is returned when the request fails or the build does not finish
+func (b *EngineBackend) Build(build ImageBuild) (string, error) {
+	query := url.Values{}
+	query.Set("t", build.Image)
+	for _, tag := range build.Tags {
+		query.Add("t", tag)
+	}
+	query.Set("rm", "1")
+	if build.NoCache {
+		query.Set("nocache", "1")
+	}
+	if build.Squash {
+		query.Set("squash", "1")
+	}
+	if len(build.Target) > 0 {
+		query.Set("target", build.Target)
+	}
+	if len(build.Platform) > 0 {
+		query.Set("platform", build.Platform)
+	}
+	if len(build.BuildArgs) > 0 {
+		buildArgs, err := json.Marshal(build.BuildArgs)
+		if err != nil {
+			return "", err
+		}
+		query.Set("buildargs", string(buildArgs))
+	}
+
+	contextReader, contextWriter := io.Pipe()
+	go func() {
+		contextWriter.CloseWithError(writeBuildContext(build.ContextPath, contextWriter))
+	}()
+	defer contextReader.Close()
+
+	req, err := http.NewRequest(http.MethodPost, b.baseURL+"/build?"+query.Encode(), contextReader)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	var imageID string
+	err = b.stream(req, func(message jsonMessage) {
+		if len(message.Aux.ID) > 0 {
+			imageID = message.Aux.ID
+		}
+		fmt.Print(message.Stream)
+	})
+	if err != nil {
+		return "", fmt.Errorf("build of %s failed: %s", build.Image, err)
+	}
+	return imageID, nil
+}
+
+// Push asks the daemon to push image, registryAuth is the base64 encoded
+// user:password for the registry or empty to push without credentials
+func (b *EngineBackend) Push(image string, registryAuth string) (string, error) {
+	repository, tag := splitTag(image)
+
+	authHeader, err := registryAuthHeader(registryAuth)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.baseURL+"/images/"+repository+"/push?tag="+url.QueryEscape(tag), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Registry-Auth", authHeader)
+
+	var digest string
+	err = b.stream(req, func(message jsonMessage) {
+		if len(message.Aux.Digest) > 0 {
+			digest = message.Aux.Digest
+		}
+		if len(message.Status) > 0 {
+			fmt.Println(message.Status)
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("push of %s failed: %s", image, err)
+	}
+	if len(digest) == 0 {
+		return "", fmt.Errorf("push of %s did not report a digest", image)
+	}
+	return digest, nil
+}
+
+// PushManifestList writes the manifest list through the registry API, as
+// the Engine API has no endpoint for manifest lists
+func (b *EngineBackend) PushManifestList(image string, images []PlatformImage, registryAuth string) (string, error) {
+	return pushManifestList(http.DefaultClient, image, images, registryAuth)
+}
+
+// ImageExists inspects image, the daemon returns 404 when it is not in the
+// local image store
+func (b *EngineBackend) ImageExists(image string) (bool, error) {
+	res, err := b.client.Get(b.baseURL + "/images/" + image + "/json")
+	if err != nil {
+		return false, fmt.Errorf("cannot connect to the Docker daemon: %s", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := ioutil.ReadAll(res.Body)
+		return false, fmt.Errorf("Docker daemon returned %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// Tag gives the local image source the name target
+func (b *EngineBackend) Tag(source string, target string) error {
+	repository, tag := splitTag(target)
+	query := url.Values{"repo": {repository}, "tag": {tag}}
+
+	res, err := b.client.Post(b.baseURL+"/images/"+source+"/tag?"+query.Encode(), "", nil)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the Docker daemon: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("cannot tag %s as %s: Docker daemon returned %d: %s", source, target, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// stream sends req and calls handle with each message of the JSON stream in
+// the response, an error message ends the stream
+func (b *EngineBackend) stream(req *http.Request, handle func(message jsonMessage)) error {
+	res, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the Docker daemon: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Docker daemon returned %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var message jsonMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("cannot read the output: %s", err)
+		}
+
+		if len(message.ErrorDetail.Message) > 0 {
+			return fmt.Errorf("%s", message.ErrorDetail.Message)
+		}
+		if len(message.Error) > 0 {
+			return fmt.Errorf("%s", message.Error)
+		}
+		handle(message)
+	}
+}
+
+// registryAuthHeader converts the user:password credentials of a Docker
+// config file to the X-Registry-Auth header of the Engine API
+func registryAuthHeader(registryAuth string) (string, error) {
+	credentials := map[string]string{}
+
+	if len(registryAuth) > 0 {
+		username, password, err := decodeRegistryAuth(registryAuth)
+		if err != nil {
+			return "", err
+		}
+		credentials["username"] = username
+		credentials["password"] = password
+	}
+
+	header, err := json.Marshal(credentials)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(header), nil
+}
+
+// writeBuildContext writes the files under contextPath to w as a tar archive,
+// symlinks are added as links rather than followed. Like docker build, the
+// paths matched by the .dockerignore file in contextPath are left out.
+func writeBuildContext(contextPath string, w io.Writer) error {
+	ignore, err := readIgnoreFiles(contextPath, ".dockerignore")
+	if err != nil {
+		return err
+	}
+
+	tarWriter := tar.NewWriter(w)
+
+	err = filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(contextPath, path)
+		if err != nil {
+			return err
+		}
+		if relative == "." {
+			return nil
+		}
+		// The Dockerfile and .dockerignore are always sent, as with docker build
+		if ignore.Matches(relative) && relative != "Dockerfile" && relative != ".dockerignore" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tarWriter.Close()
+}
@@ -0,0 +1,262 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_EngineBackend_Build(t *testing.T) {
+	contextPath, err := ioutil.TempDir("", "faas-cli-engine")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(contextPath)
+
+	if err := os.MkdirAll(filepath.Join(contextPath, "function"), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	for name, contents := range map[string]string{
+		"Dockerfile":          "FROM alpine:3.7\n",
+		".dockerignore":       "*.md\n",
+		"README.md":           "# figlet\n",
+		"function/handler.go": "package function\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(contextPath, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	var gotQuery map[string]string
+	var gotFiles []string
+	var gotDockerfile string
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/build" {
+			t.Errorf("want POST /build, got %s %s", r.Method, r.URL.Path)
+		}
+
+		gotQuery = map[string]string{}
+		for k := range r.URL.Query() {
+			gotQuery[k] = r.URL.Query().Get(k)
+		}
+
+		tarReader := tar.NewReader(r.Body)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("Error reading build context: %s", err)
+				return
+			}
+			gotFiles = append(gotFiles, header.Name)
+			if header.Name == "Dockerfile" {
+				data, _ := ioutil.ReadAll(tarReader)
+				gotDockerfile = string(data)
+			}
+		}
+
+		w.Write([]byte(`{"stream":"Step 1/1 : FROM alpine:3.7\n"}` + "\n" + `{"aux":{"ID":"sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba"}}` + "\n" + `{"stream":"Successfully built 4e38e38c8ce0\n"}` + "\n"))
+	}))
+
+	socketPath, err := ioutil.TempDir("", "faas-cli-socket")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(socketPath)
+
+	socket := filepath.Join(socketPath, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	backend, err := NewEngineBackend("unix://" + socket)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	imageID, err := backend.Build(ImageBuild{
+		Image:       "alexellis/figlet:0.1",
+		ContextPath: contextPath,
+		NoCache:     true,
+		BuildArgs:   map[string]string{"GO111MODULE": "off"},
+	})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if imageID != "sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba" {
+		t.Errorf("want the image ID from the aux message, got %q", imageID)
+	}
+
+	wantQuery := map[string]string{
+		"t":         "alexellis/figlet:0.1",
+		"rm":        "1",
+		"nocache":   "1",
+		"buildargs": `{"GO111MODULE":"off"}`,
+	}
+	if len(gotQuery) != len(wantQuery) {
+		t.Errorf("want query %v, got %v", wantQuery, gotQuery)
+	}
+	for k, v := range wantQuery {
+		if gotQuery[k] != v {
+			t.Errorf("want query %s=%s, got %q", k, v, gotQuery[k])
+		}
+	}
+
+	sort.Strings(gotFiles)
+	wantFiles := ".dockerignore,Dockerfile,function/,function/handler.go"
+	if strings.Join(gotFiles, ",") != wantFiles {
+		t.Errorf("want build context %s, got %s", wantFiles, strings.Join(gotFiles, ","))
+	}
+	if gotDockerfile != "FROM alpine:3.7\n" {
+		t.Errorf("want Dockerfile contents in the build context, got %q", gotDockerfile)
+	}
+}
+
+func Test_EngineBackend_BuildError(t *testing.T) {
+	contextPath, err := ioutil.TempDir("", "faas-cli-engine")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(contextPath)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"stream":"Step 1/2 : FROM alpine:3.7\n"}` + "\n" +
+			`{"errorDetail":{"code":1,"message":"The command '/bin/sh -c exit 1' returned a non-zero code: 1"},"error":"The command '/bin/sh -c exit 1' returned a non-zero code: 1"}` + "\n"))
+	}))
+	defer server.Close()
+
+	backend, err := NewEngineBackend(strings.Replace(server.URL, "http://", "tcp://", 1))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	_, err = backend.Build(ImageBuild{Image: "alexellis/figlet:0.1", ContextPath: contextPath})
+	want := "build of alexellis/figlet:0.1 failed: The command '/bin/sh -c exit 1' returned a non-zero code: 1"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
+
+func Test_EngineBackend_Push(t *testing.T) {
+	var gotPath, gotTag, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotTag = r.URL.Query().Get("tag")
+		gotAuth = r.Header.Get("X-Registry-Auth")
+		w.Write([]byte(`{"status":"The push refers to repository [docker.io/alexellis/figlet]"}` + "\n" +
+			`{"status":"0.1: digest: sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba size: 1573"}` + "\n" +
+			`{"progressDetail":{},"aux":{"Tag":"0.1","Digest":"sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba","Size":1573}}` + "\n"))
+	}))
+	defer server.Close()
+
+	backend, err := NewEngineBackend(strings.Replace(server.URL, "http://", "tcp://", 1))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	// "alexellis:secret" encoded as it is in ~/.docker/config.json
+	digest, err := backend.Push("alexellis/figlet:0.1", "YWxleGVsbGlzOnNlY3JldA==")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if digest != "sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba" {
+		t.Errorf("want the digest from the push, got %q", digest)
+	}
+
+	if gotPath != "/images/alexellis/figlet/push" || gotTag != "0.1" {
+		t.Errorf("want a push of alexellis/figlet with the tag 0.1, got %s %s", gotPath, gotTag)
+	}
+
+	decoded, err := base64.URLEncoding.DecodeString(gotAuth)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	credentials := map[string]string{}
+	if err := json.Unmarshal(decoded, &credentials); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if credentials["username"] != "alexellis" || credentials["password"] != "secret" {
+		t.Errorf("want the registry credentials in X-Registry-Auth, got %s", decoded)
+	}
+}
+
+func Test_EngineBackend_ImageExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/alexellis/figlet:0.1/json" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No such image"}`))
+			return
+		}
+		w.Write([]byte(`{"Id":"sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba"}`))
+	}))
+	defer server.Close()
+
+	backend, err := NewEngineBackend(strings.Replace(server.URL, "http://", "tcp://", 1))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	for image, want := range map[string]bool{"alexellis/figlet:0.1": true, "alexellis/figlet:0.2": false} {
+		exists, err := backend.ImageExists(image)
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		if exists != want {
+			t.Errorf("want ImageExists(%q) to be %t, got %t", image, want, exists)
+		}
+	}
+}
+
+func Test_EngineBackend_Tag(t *testing.T) {
+	var gotPath, gotRepo, gotTag string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotRepo = r.URL.Query().Get("repo")
+		gotTag = r.URL.Query().Get("tag")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	backend, err := NewEngineBackend(strings.Replace(server.URL, "http://", "tcp://", 1))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if err := backend.Tag("alexellis/figlet:4e38e38c8ce0", "registry.example.com:5000/figlet:0.1"); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if gotPath != "/images/alexellis/figlet:4e38e38c8ce0/tag" || gotRepo != "registry.example.com:5000/figlet" || gotTag != "0.1" {
+		t.Errorf("want a tag of registry.example.com:5000/figlet:0.1, got %s repo=%s tag=%s", gotPath, gotRepo, gotTag)
+	}
+}
+
+func Test_NewBackend(t *testing.T) {
+	if _, err := NewBackend("kaniko"); err == nil || err.Error() != `unknown builder "kaniko", use cli or api` {
+		t.Fatalf("want an unknown builder error, got %v", err)
+	}
+
+	if _, err := NewEngineBackend("ssh://docker@build-host"); err == nil {
+		t.Fatalf("want an error for an unsupported Docker host")
+	}
+}
@@ -4,24 +4,56 @@
 package builder
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/morikuni/aec"
 )
 
 // ExecCommand run a system command
 func ExecCommand(tempPath string, builder []string) {
+	if err := RunCommand(tempPath, builder); err != nil {
+		errString := fmt.Sprintf("ERROR - Could not execute command: %s", builder)
+		log.Fatal(aec.RedF.Apply(errString))
+	}
+}
+
+// RunCommand runs a system command in tempPath and returns an error when it
+// cannot be started or exits with a non-zero status
+func RunCommand(tempPath string, builder []string) error {
 	targetCmd := exec.Command(builder[0], builder[1:]...)
 	targetCmd.Dir = tempPath
 	targetCmd.Stdout = os.Stdout
 	targetCmd.Stderr = os.Stderr
-	targetCmd.Start()
-	err := targetCmd.Wait()
-	if err != nil {
-		errString := fmt.Sprintf("ERROR - Could not execute command: %s", builder)
-		log.Fatalf(aec.RedF.Apply(errString))
+
+	if err := targetCmd.Run(); err != nil {
+		return fmt.Errorf("could not execute command: %s: %s", builder, err)
+	}
+	return nil
+}
+
+// RunCommandOutput runs a system command like RunCommand and also returns
+// what it wrote to stdout. The last line written to stderr is added to the
+// error so callers can tell why it failed.
+func RunCommandOutput(tempPath string, builder []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	targetCmd := exec.Command(builder[0], builder[1:]...)
+	targetCmd.Dir = tempPath
+	targetCmd.Stdout = io.MultiWriter(os.Stdout, &stdout)
+	targetCmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+
+	if err := targetCmd.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); len(last) > 0 {
+			return stdout.String(), fmt.Errorf("could not execute command: %s: %s: %s", builder, err, last)
+		}
+		return stdout.String(), fmt.Errorf("could not execute command: %s: %s", builder, err)
 	}
+	return stdout.String(), nil
 }
@@ -0,0 +1,156 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuildCacheDir holds the hash of the inputs of each function's last build
+const BuildCacheDir = "./build/.cache"
+
+// BuildHash returns a sha256 over the inputs of a function build: the
+// image name, the language template, the handler folder, the build args, the
+// build target, squash, the build options and the platforms. The same inputs
+// always give the same hash.
+func BuildHash(image string, handler string, language string, buildArgMap map[string]string, buildTarget string, squash bool, buildOptions []string, platforms []string) (string, error) {
+	digest := sha256.New()
+
+	fmt.Fprintf(digest, "image %s\n", image)
+	fmt.Fprintf(digest, "language %s\n", strings.ToLower(language))
+
+	if strings.ToLower(language) != "dockerfile" {
+		if err := hashFolder(digest, "template", "./template/"+strings.ToLower(language)); err != nil {
+			return "", err
+		}
+	}
+	if err := hashFolder(digest, "handler", handler); err != nil {
+		return "", err
+	}
+
+	var keys []string
+	for k := range buildArgMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(digest, "build-arg %s=%s\n", k, buildArgMap[k])
+	}
+
+	fmt.Fprintf(digest, "build-target %s\n", buildTarget)
+	fmt.Fprintf(digest, "squash %t\n", squash)
+
+	for _, option := range buildOptions {
+		fmt.Fprintf(digest, "build-option %s\n", option)
+	}
+
+	for _, platform := range platforms {
+		fmt.Fprintf(digest, "platform %s\n", platform)
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// FolderHash returns a sha256 over the paths, modes and contents of the files
+// in a folder, leaving out the files matched by its ignore files
+func FolderHash(root string) (string, error) {
+	digest := sha256.New()
+	if err := hashFolder(digest, "file", root); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// hashFolder writes the path, mode and contents of each file under root to
+// digest, filepath.Walk visits files in lexical order. Files left out of the
+// build context by ignore files are left out of the hash, and symlinks are
+// hashed by their target as they are copied as symlinks.
+func hashFolder(digest hash.Hash, prefix string, root string) error {
+	ignore, err := readIgnoreFiles(root, IgnoreFiles...)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if ignore.Matches(relative) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		fmt.Fprintf(digest, "%s %s %s\n", prefix, filepath.ToSlash(relative), info.Mode())
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := readInsideSymlink(root, path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(digest, "link %s\n", target)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return hashFile(digest, path)
+	})
+}
+
+func hashFile(digest hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(digest, file)
+	return err
+}
+
+// CachedBuildHash returns the hash saved by the last build of a function, or
+// an empty string when it has not been built
+func CachedBuildHash(functionName string) string {
+	data, err := ioutil.ReadFile(filepath.Join(BuildCacheDir, functionName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SaveBuildHash records the hash of a successful build of a function
+func SaveBuildHash(functionName string, buildHash string) error {
+	if err := os.MkdirAll(BuildCacheDir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(BuildCacheDir, functionName), []byte(buildHash+"\n"), 0600)
+}
+
+// HashTag returns image tagged with the first 12 characters of buildHash,
+// such as alexellis/figlet:4e38e38c8ce0
+func HashTag(image string, buildHash string) string {
+	repository := image
+	if index := strings.Index(repository, "@"); index > -1 {
+		repository = repository[:index]
+	}
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		repository = repository[:index]
+	}
+	return repository + ":" + buildHash[:12]
+}
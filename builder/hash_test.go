@@ -0,0 +1,134 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_BuildHash(t *testing.T) {
+	handler, err := ioutil.TempDir("", "faas-cli-hash")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(handler)
+
+	if err := ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	hash := func(image string, buildArgs map[string]string, buildOptions []string) string {
+		value, err := BuildHash(image, handler, "dockerfile", buildArgs, "", false, buildOptions, nil)
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		return value
+	}
+
+	first := hash("alexellis/figlet", map[string]string{"a": "1", "b": "2"}, nil)
+	if len(first) != 64 {
+		t.Fatalf("want a sha256 hex digest, got %q", first)
+	}
+	if again := hash("alexellis/figlet", map[string]string{"b": "2", "a": "1"}, nil); again != first {
+		t.Errorf("want the same hash for the same inputs, got %s and %s", first, again)
+	}
+
+	for title, changed := range map[string]string{
+		"image":        hash("alexellis/figlet:0.2", map[string]string{"a": "1", "b": "2"}, nil),
+		"build-arg":    hash("alexellis/figlet", map[string]string{"a": "1", "b": "3"}, nil),
+		"build-option": hash("alexellis/figlet", map[string]string{"a": "1", "b": "2"}, []string{"dev"}),
+	} {
+		if changed == first {
+			t.Errorf("want a new hash when the %s changes", title)
+		}
+	}
+
+	squashed, err := BuildHash("alexellis/figlet", handler, "dockerfile", map[string]string{"a": "1", "b": "2"}, "", true, nil, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if squashed == first {
+		t.Errorf("want a new hash when --squash changes")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.8\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if changed := hash("alexellis/figlet", map[string]string{"a": "1", "b": "2"}, nil); changed == first {
+		t.Errorf("want a new hash when the handler changes")
+	}
+
+	if _, err := BuildHash("alexellis/figlet", filepath.Join(handler, "missing"), "dockerfile", nil, "", false, nil, nil); err == nil {
+		t.Errorf("want an error for a missing handler")
+	}
+}
+
+func Test_FolderHash_OutsideSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-hash")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	handler := filepath.Join(dir, "handler")
+	if err := os.MkdirAll(filepath.Join(handler, "lib"), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := os.Symlink("lib", filepath.Join(handler, "vendor")); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := FolderHash(handler); err != nil {
+		t.Fatalf("want a symlink inside the handler to be hashed, got: %s", err)
+	}
+
+	if err := os.Symlink("../shared", filepath.Join(handler, "shared")); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := FolderHash(handler); err == nil || !strings.Contains(err.Error(), "points outside") {
+		t.Fatalf("want an error for a symlink outside the handler, got %v", err)
+	}
+}
+
+func Test_SaveBuildHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-hash")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.Chdir(cwd)
+
+	if cached := CachedBuildHash("figlet"); cached != "" {
+		t.Fatalf("want no hash before a build, got %q", cached)
+	}
+	if err := SaveBuildHash("figlet", "4e38e38c8ce0"); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if cached := CachedBuildHash("figlet"); cached != "4e38e38c8ce0" {
+		t.Fatalf("want the saved hash, got %q", cached)
+	}
+}
+
+func Test_HashTag(t *testing.T) {
+	buildHash := "4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba"
+	for image, want := range map[string]string{
+		"alexellis/figlet":                        "alexellis/figlet:4e38e38c8ce0",
+		"alexellis/figlet:0.1":                    "alexellis/figlet:4e38e38c8ce0",
+		"registry.example.com:5000/team/figlet":   "registry.example.com:5000/team/figlet:4e38e38c8ce0",
+		"registry.example.com:5000/team/figlet:1": "registry.example.com:5000/team/figlet:4e38e38c8ce0",
+		"alexellis/figlet@sha256:4e38e38c8ce0":    "alexellis/figlet:4e38e38c8ce0",
+	} {
+		if got := HashTag(image, buildHash); got != want {
+			t.Errorf("HashTag(%q) want %q, got %q", image, want, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFiles are read from a handler or template folder for the patterns
+// of paths to leave out of the build context
+var IgnoreFiles = []string{".dockerignore", ".faasignore"}
+
+// ignoreMatcher matches relative paths against .dockerignore patterns, the
+// last pattern to match a path decides and "!" patterns include it again
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	pattern   *regexp.Regexp
+	exception bool
+}
+
+// readIgnoreFiles reads the patterns of each of names in dir, a missing
+// file has no patterns
+func readIgnoreFiles(dir string, names ...string) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+
+	for _, name := range names {
+		file, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			if err := matcher.add(line); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s: %s", filepath.Join(dir, name), err)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return matcher, nil
+}
+
+func (m *ignoreMatcher) add(line string) error {
+	exception := strings.HasPrefix(line, "!")
+	if exception {
+		line = strings.TrimSpace(line[1:])
+	}
+
+	line = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(line)), "/")
+
+	pattern, err := regexp.Compile("^" + ignoreExpression(line) + "$")
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %s", line, err)
+	}
+
+	m.patterns = append(m.patterns, ignorePattern{pattern: pattern, exception: exception})
+	return nil
+}
+
+// ignoreExpression converts a pattern to a regular expression, "*" and "?"
+// do not match "/" while "**" matches any number of folders
+func ignoreExpression(pattern string) string {
+	var expression bytes.Buffer
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				expression.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				expression.WriteString(".*")
+				i++
+			} else {
+				expression.WriteString("[^/]*")
+			}
+		case '?':
+			expression.WriteString("[^/]")
+		case '[':
+			end := strings.Index(pattern[i:], "]")
+			if end == -1 {
+				expression.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return expression.String()
+}
+
+// Matches is true when relative, a slash separated path inside the folder
+// the patterns were read from, or one of its parent folders is ignored
+func (m *ignoreMatcher) Matches(relative string) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+
+	relative = filepath.ToSlash(filepath.Clean(relative))
+	if relative == "." || strings.HasPrefix(relative, "../") {
+		return false
+	}
+
+	ignored := false
+	for _, pattern := range m.patterns {
+		if matchesPathOrParent(pattern.pattern, relative) {
+			ignored = !pattern.exception
+		}
+	}
+	return ignored
+}
+
+func matchesPathOrParent(pattern *regexp.Regexp, relative string) bool {
+	for path := relative; ; {
+		if pattern.MatchString(path) {
+			return true
+		}
+
+		index := strings.LastIndex(path, "/")
+		if index == -1 {
+			return false
+		}
+		path = path[:index]
+	}
+}
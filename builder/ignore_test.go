@@ -0,0 +1,49 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"testing"
+)
+
+func Test_ignoreMatcher_Matches(t *testing.T) {
+	matcher := &ignoreMatcher{}
+	for _, line := range []string{
+		"node_modules",
+		"*.md",
+		"!README.md",
+		"**/*.test.js",
+		"/tmp",
+		"fixtures/data?.json",
+	} {
+		if err := matcher.add(line); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	for path, want := range map[string]bool{
+		"node_modules":                  true,
+		"node_modules/express/index.js": true,
+		"lib/node_modules":              false,
+		"CHANGELOG.md":                  true,
+		"README.md":                     false,
+		"docs/CHANGELOG.md":             false,
+		"handler.test.js":               true,
+		"lib/deep/handler.test.js":      true,
+		"handler.js":                    false,
+		"tmp/cache":                     true,
+		"fixtures/data1.json":           true,
+		"fixtures/data10.json":          false,
+		"../node_modules":               false,
+	} {
+		if got := matcher.Matches(path); got != want {
+			t.Errorf("Matches(%q) want %v, got %v", path, want, got)
+		}
+	}
+
+	var none *ignoreMatcher
+	if none.Matches("node_modules") {
+		t.Errorf("want a nil matcher to match nothing")
+	}
+}
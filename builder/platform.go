@@ -0,0 +1,53 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlatformImage is the image built for one platform of a multi-arch image
+type PlatformImage struct {
+	// Platform such as linux/amd64 or linux/arm/v7
+	Platform string
+	Image    string
+}
+
+// PlatformImageName returns the image of one platform, the platform is
+// added to the tag such as alexellis/figlet:0.1-linux-arm64
+func PlatformImageName(image string, platform string) string {
+	repository, tag := splitTag(image)
+	return repository + ":" + tag + "-" + strings.Replace(platform, "/", "-", -1)
+}
+
+// ParsePlatform splits a platform such as linux/arm/v7 into its operating
+// system, architecture and optional variant
+func ParsePlatform(platform string) (string, string, string, error) {
+	parts := strings.Split(platform, "/")
+	for _, part := range parts {
+		if len(part) == 0 {
+			parts = nil
+			break
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return parts[0], parts[1], "", nil
+	case 3:
+		return parts[0], parts[1], parts[2], nil
+	default:
+		return "", "", "", fmt.Errorf("invalid platform %q, use os/arch or os/arch/variant such as linux/arm64", platform)
+	}
+}
+
+// splitTag splits an image into its repository and tag, the tag is latest
+// when there is none
+func splitTag(image string) (string, string) {
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		return image[:index], image[index+1:]
+	}
+	return image, "latest"
+}
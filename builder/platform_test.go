@@ -0,0 +1,40 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import "testing"
+
+func Test_PlatformImageName(t *testing.T) {
+	testCases := []struct {
+		image    string
+		platform string
+		want     string
+	}{
+		{image: "alexellis/figlet", platform: "linux/amd64", want: "alexellis/figlet:latest-linux-amd64"},
+		{image: "alexellis/figlet:0.1", platform: "linux/arm/v7", want: "alexellis/figlet:0.1-linux-arm-v7"},
+		{image: "registry:5000/figlet", platform: "linux/arm64", want: "registry:5000/figlet:latest-linux-arm64"},
+	}
+
+	for _, testCase := range testCases {
+		if got := PlatformImageName(testCase.image, testCase.platform); got != testCase.want {
+			t.Errorf("PlatformImageName(%q, %q) want %q, got %q", testCase.image, testCase.platform, testCase.want, got)
+		}
+	}
+}
+
+func Test_ParsePlatform(t *testing.T) {
+	os, arch, variant, err := ParsePlatform("linux/arm/v7")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if os != "linux" || arch != "arm" || variant != "v7" {
+		t.Fatalf("want linux arm v7, got %s %s %s", os, arch, variant)
+	}
+
+	for _, platform := range []string{"linux", "linux/", "/amd64", "linux/arm/v7/extra"} {
+		if _, _, _, err := ParsePlatform(platform); err == nil {
+			t.Errorf("want an error for the platform %q", platform)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	dockerManifestType     = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerManifestListType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociManifestType        = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexType           = "application/vnd.oci.image.index.v1+json"
+
+	// dockerHubRegistry serves the registry API of the Docker Hub
+	dockerHubRegistry = "registry-1.docker.io"
+)
+
+// challengeParam matches one key="value" of a WWW-Authenticate header
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// manifestList is the manifest list, or OCI image index, of a multi-arch image
+type manifestList struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	Manifests     []manifestDescriptor `json:"manifests"`
+}
+
+type manifestDescriptor struct {
+	MediaType string           `json:"mediaType"`
+	Size      int              `json:"size"`
+	Digest    string           `json:"digest"`
+	Platform  manifestPlatform `json:"platform"`
+}
+
+type manifestPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// registryClient calls the HTTP API v2 of the registry of one repository
+type registryClient struct {
+	baseURL      string
+	repository   string
+	registryAuth string
+	client       *http.Client
+
+	// authorization is the header which the registry last accepted
+	authorization string
+}
+
+// newRegistryClient returns a client for the repository of image and its
+// tag. Registries on this machine are called over plain HTTP.
+func newRegistryClient(client *http.Client, image string, registryAuth string) (*registryClient, string) {
+	repository, tag := splitTag(image)
+
+	host := dockerHubRegistry
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		host, repository = parts[0], parts[1]
+	}
+	switch host {
+	case "docker.io", "index.docker.io":
+		host = dockerHubRegistry
+	}
+	if host == dockerHubRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	scheme := "https"
+	if hostname := strings.Split(host, ":")[0]; hostname == "localhost" || hostname == "127.0.0.1" {
+		scheme = "http"
+	}
+
+	return &registryClient{
+		baseURL:      scheme + "://" + host,
+		repository:   repository,
+		registryAuth: registryAuth,
+		client:       client,
+	}, tag
+}
+
+// pushManifestList writes a manifest list of images, which must already be
+// pushed to the same repository, to the registry under the tag of image and
+// returns its digest
+func pushManifestList(client *http.Client, image string, images []PlatformImage, registryAuth string) (string, error) {
+	registry, tag := newRegistryClient(client, image, registryAuth)
+
+	list := manifestList{SchemaVersion: 2, MediaType: dockerManifestListType}
+	for _, platformImage := range images {
+		operatingSystem, arch, variant, err := ParsePlatform(platformImage.Platform)
+		if err != nil {
+			return "", err
+		}
+
+		_, platformTag := splitTag(platformImage.Image)
+		descriptor, err := registry.manifest(platformTag)
+		if err != nil {
+			return "", fmt.Errorf("cannot read the manifest of %s: %s", platformImage.Image, err)
+		}
+		if descriptor.MediaType == ociManifestType {
+			list.MediaType = ociIndexType
+		}
+
+		descriptor.Platform = manifestPlatform{OS: operatingSystem, Architecture: arch, Variant: variant}
+		list.Manifests = append(list.Manifests, descriptor)
+	}
+
+	body, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := registry.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, registry.manifestURL(tag), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", list.MediaType)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		return "", registryError(res)
+	}
+
+	if digest := res.Header.Get("Docker-Content-Digest"); len(digest) > 0 {
+		return digest, nil
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// manifest returns the media type, size and digest of the manifest of a tag
+func (r *registryClient) manifest(tag string) (manifestDescriptor, error) {
+	res, err := r.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, r.manifestURL(tag), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join([]string{dockerManifestType, ociManifestType}, ", "))
+		return req, nil
+	})
+	if err != nil {
+		return manifestDescriptor{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return manifestDescriptor{}, registryError(res)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return manifestDescriptor{}, err
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(res.Header.Get("Content-Type"), ";")[0])
+	if mediaType != dockerManifestType && mediaType != ociManifestType {
+		return manifestDescriptor{}, fmt.Errorf("want a single platform image, got a manifest of type %q", mediaType)
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if len(digest) == 0 {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return manifestDescriptor{MediaType: mediaType, Size: len(body), Digest: digest}, nil
+}
+
+func (r *registryClient) manifestURL(reference string) string {
+	return r.baseURL + "/v2/" + r.repository + "/manifests/" + url.PathEscape(reference)
+}
+
+// do sends the request made by newRequest, and when the registry asks for
+// credentials sends it again with a basic auth header or a bearer token
+// from the registry's token server
+func (r *registryClient) do(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if len(r.authorization) > 0 {
+			req.Header.Set("Authorization", r.authorization)
+		}
+
+		res, err := r.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect to the registry: %s", err)
+		}
+		if res.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return res, nil
+		}
+
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+
+		if r.authorization, err = r.authorize(challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authorize answers the WWW-Authenticate challenge of the registry
+func (r *registryClient) authorize(challenge string) (string, error) {
+	username, password, err := decodeRegistryAuth(r.registryAuth)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	if scheme == "basic" {
+		if len(r.registryAuth) == 0 {
+			return "", fmt.Errorf("the registry needs credentials, run docker login")
+		}
+		return "Basic " + r.registryAuth, nil
+	}
+	if scheme != "bearer" {
+		return "", fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if len(params["realm"]) == 0 {
+		return "", fmt.Errorf("the registry did not give a token server: %q", challenge)
+	}
+
+	query := url.Values{"scope": {"repository:" + r.repository + ":pull,push"}}
+	if len(params["service"]) > 0 {
+		query.Set("service", params["service"])
+	}
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if len(r.registryAuth) > 0 {
+		req.SetBasicAuth(username, password)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cannot connect to the token server of the registry: %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cannot get a registry token: %s", registryError(res))
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("cannot read the registry token: %s", err)
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// decodeRegistryAuth splits the base64 encoded user:password of a Docker
+// config file, empty credentials give an empty user and password
+func decodeRegistryAuth(registryAuth string) (string, string, error) {
+	if len(registryAuth) == 0 {
+		return "", "", nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(registryAuth)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid registry credentials: %s", err)
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid registry credentials, want user:password")
+	}
+	return parts[0], parts[1], nil
+}
+
+func registryError(res *http.Response) error {
+	body, _ := ioutil.ReadAll(res.Body)
+	return fmt.Errorf("registry returned %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
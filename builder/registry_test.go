@@ -0,0 +1,116 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_pushManifestList(t *testing.T) {
+	manifests := map[string]string{
+		"0.1-linux-amd64":  `{"schemaVersion":2,"layers":["amd64"]}`,
+		"0.1-linux-arm-v7": `{"schemaVersion":2,"layers":["arm"]}`,
+	}
+
+	var gotScope, gotBasicAuth, gotContentType string
+	var gotList manifestList
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			gotScope = r.URL.Query().Get("scope")
+			user, password, _ := r.BasicAuth()
+			gotBasicAuth = user + ":" + password
+			w.Write([]byte(`{"token":"abc"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		tag := strings.TrimPrefix(r.URL.Path, "/v2/alexellis/figlet/manifests/")
+		switch r.Method {
+		case http.MethodGet:
+			manifest, ok := manifests[tag]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", dockerManifestType)
+			w.Header().Set("Docker-Content-Digest", "sha256:"+tag)
+			w.Write([]byte(manifest))
+		case http.MethodPut:
+			gotContentType = r.Header.Get("Content-Type")
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &gotList)
+			w.Header().Set("Docker-Content-Digest", "sha256:list")
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/alexellis/figlet:0.1"
+	images := []PlatformImage{
+		{Platform: "linux/amd64", Image: PlatformImageName(image, "linux/amd64")},
+		{Platform: "linux/arm/v7", Image: PlatformImageName(image, "linux/arm/v7")},
+	}
+
+	// "alexellis:secret" encoded as it is in ~/.docker/config.json
+	digest, err := pushManifestList(http.DefaultClient, image, images, "YWxleGVsbGlzOnNlY3JldA==")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if digest != "sha256:list" {
+		t.Errorf("want the digest of the manifest list, got %q", digest)
+	}
+
+	if gotScope != "repository:alexellis/figlet:pull,push" || gotBasicAuth != "alexellis:secret" {
+		t.Errorf("want a push token for alexellis/figlet with the registry credentials, got scope %q with %q", gotScope, gotBasicAuth)
+	}
+	if gotContentType != dockerManifestListType {
+		t.Errorf("want the manifest list type, got %q", gotContentType)
+	}
+
+	want := []manifestDescriptor{
+		{MediaType: dockerManifestType, Size: len(manifests["0.1-linux-amd64"]), Digest: "sha256:0.1-linux-amd64", Platform: manifestPlatform{OS: "linux", Architecture: "amd64"}},
+		{MediaType: dockerManifestType, Size: len(manifests["0.1-linux-arm-v7"]), Digest: "sha256:0.1-linux-arm-v7", Platform: manifestPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+	}
+	if len(gotList.Manifests) != len(want) {
+		t.Fatalf("want %d manifests in the list, got %v", len(want), gotList.Manifests)
+	}
+	for i := range want {
+		if gotList.Manifests[i] != want[i] {
+			t.Errorf("want manifest %d to be %v, got %v", i, want[i], gotList.Manifests[i])
+		}
+	}
+}
+
+func Test_newRegistryClient(t *testing.T) {
+	cases := []struct {
+		image      string
+		baseURL    string
+		repository string
+		tag        string
+	}{
+		{"figlet", "https://registry-1.docker.io", "library/figlet", "latest"},
+		{"alexellis/figlet:0.1", "https://registry-1.docker.io", "alexellis/figlet", "0.1"},
+		{"docker.io/alexellis/figlet:0.1", "https://registry-1.docker.io", "alexellis/figlet", "0.1"},
+		{"registry.example.com:5000/team/figlet:0.1", "https://registry.example.com:5000", "team/figlet", "0.1"},
+		{"localhost:5000/figlet:0.1", "http://localhost:5000", "figlet", "0.1"},
+	}
+
+	for _, c := range cases {
+		registry, tag := newRegistryClient(http.DefaultClient, c.image, "")
+		if registry.baseURL != c.baseURL || registry.repository != c.repository || tag != c.tag {
+			t.Errorf("%s: want %s %s %s, got %s %s %s", c.image, c.baseURL, c.repository, c.tag, registry.baseURL, registry.repository, tag)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas/gateway/requests"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// stackLabel marks the functions deployed by "faas-cli apply" for a stack
+	stackLabel = "com.openfaas.cli.stack"
+
+	// specHashLabel holds a hash of the deployed spec so changes to fields
+	// the gateway does not report, such as env vars and secrets, are detected
+	specHashLabel = "com.openfaas.cli.spec-hash"
+
+	// maxLabelValueLength is the longest label value Kubernetes accepts
+	maxLabelValueLength = 63
+)
+
+var (
+	applyFlags     DeployFlags
+	applyPrune     bool
+	applyDryRun    bool
+	applyStackName string
+)
+
+var validStackName = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`)
+
+func init() {
+	applyCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	applyCmd.Flags().StringVar(&network, "network", defaultNetwork, "Name of the network")
+
+	applyCmd.Flags().StringArrayVarP(&applyFlags.envvarOpts, "env", "e", []string{}, "Set one or more environment variables (ENVVAR=VALUE)")
+	applyCmd.Flags().StringArrayVarP(&applyFlags.labelOpts, "label", "l", []string{}, "Set one or more label (LABEL=VALUE)")
+	applyCmd.Flags().StringArrayVar(&applyFlags.constraints, "constraint", []string{}, "Apply a constraint to the function")
+	applyCmd.Flags().StringArrayVar(&applyFlags.secrets, "secret", []string{}, "Give the function access to a secure secret")
+	applyCmd.Flags().BoolVarP(&applyFlags.sendRegistryAuth, "send-registry-auth", "a", false, "send registryAuth from Docker credentials manager with the request")
+
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Remove functions previously applied from this stack which are no longer in the YAML file")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the plan without changing the gateway")
+	applyCmd.Flags().StringVar(&applyStackName, "stack-name", "", "Name used to track the functions owned by this stack (defaults to the YAML file name and a hash of its path)")
+
+	faasCmd.AddCommand(applyCmd)
+}
+
+// applyCmd reconciles the functions on the gateway with a stack file
+var applyCmd = &cobra.Command{
+	Use: `apply -f YAML_FILE [--prune] [--dry-run]
+                  [--gateway GATEWAY_URL]
+                  [--stack-name NAME]
+                  [--env ENVVAR=VALUE ...]
+                  [--label LABEL=VALUE ...]
+                  [--secret "SECRET_NAME"]`,
+	Short: "Reconcile the functions on the gateway with a stack file",
+	Long: `Compares the functions in the YAML file with the ones deployed on the gateway,
+prints a plan and then creates, updates or removes functions to match it.
+
+Functions deployed by apply are labelled with ` + stackLabel + ` so that
+--prune only removes functions which were previously applied from the same stack.`,
+	Example: `  faas-cli apply -f ./stack.yml
+  faas-cli apply -f ./stack.yml --dry-run
+  faas-cli apply -f ./stack.yml --prune
+  faas-cli apply -f ./stack.yml --stack-name payments --prune`,
+	RunE: runApply,
+}
+
+// applyPlan holds the function names for each action, sorted by name
+type applyPlan struct {
+	Create    []string
+	Update    []string
+	Unchanged []string
+	Delete    []string
+
+	// Unpruned are owned by the stack but missing from the YAML file, they
+	// are only deleted with --prune
+	Unpruned []string
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with --yaml/-f")
+	}
+
+	if applyPrune && (len(regex) > 0 || len(filter) > 0) {
+		return fmt.Errorf("--prune cannot be used with --regex or --filter as functions filtered out of the stack would be removed")
+	}
+
+	services, err := parseStack(stackFiles(), regex, filter)
+	if err != nil {
+		return err
+	}
+
+	services.Provider.GatewayURL = getGatewayURL(gateway, defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+	if len(network) > 0 {
+		services.Provider.Network = network
+	} else if len(services.Provider.Network) == 0 {
+		services.Provider.Network = contextNetwork()
+	}
+
+	stackName := applyStackName
+	if len(stackName) == 0 {
+		stackName = defaultStackName(yamlFile)
+	} else if len(stackName) > maxLabelValueLength || !validStackName.MatchString(stackName) {
+		return fmt.Errorf("invalid --stack-name %q, use up to %d letters, digits, '-', '_' or '.'", stackName, maxLabelValueLength)
+	}
+
+	desired := make(map[string]*proxy.DeployFunctionSpec)
+	for name, function := range services.Functions {
+		function.Name = name
+
+		spec, err := makeDeploySpec(function, *services, applyFlags)
+		if err != nil {
+			return err
+		}
+
+		spec.Labels[stackLabel] = stackName
+		spec.Labels[specHashLabel] = hashDeploySpec(spec)
+		desired[name] = spec
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(services.Provider.GatewayURL, &timeout)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := client.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan := makeApplyPlan(stackName, desired, deployed, applyPrune)
+	printApplyPlan(stackName, client.GatewayURL, plan)
+
+	if applyDryRun {
+		return nil
+	}
+
+	return executeApplyPlan(ctx, client, plan, desired)
+}
+
+// makeApplyPlan compares the desired specs with the deployed functions
+func makeApplyPlan(stackName string, desired map[string]*proxy.DeployFunctionSpec, deployed []requests.Function, prune bool) applyPlan {
+	plan := applyPlan{}
+
+	deployedByName := make(map[string]requests.Function)
+	for _, function := range deployed {
+		deployedByName[function.Name] = function
+	}
+
+	for name, spec := range desired {
+		function, exists := deployedByName[name]
+		switch {
+		case !exists:
+			plan.Create = append(plan.Create, name)
+		case function.Image == spec.Image &&
+			functionLabel(function, stackLabel) == stackName &&
+			functionLabel(function, specHashLabel) == spec.Labels[specHashLabel]:
+			plan.Unchanged = append(plan.Unchanged, name)
+		default:
+			plan.Update = append(plan.Update, name)
+		}
+	}
+
+	for _, function := range deployed {
+		if _, inStack := desired[function.Name]; inStack {
+			continue
+		}
+		if functionLabel(function, stackLabel) != stackName {
+			continue
+		}
+
+		if prune {
+			plan.Delete = append(plan.Delete, function.Name)
+		} else {
+			plan.Unpruned = append(plan.Unpruned, function.Name)
+		}
+	}
+
+	sort.Strings(plan.Create)
+	sort.Strings(plan.Update)
+	sort.Strings(plan.Unchanged)
+	sort.Strings(plan.Delete)
+	sort.Strings(plan.Unpruned)
+
+	return plan
+}
+
+func printApplyPlan(stackName string, gatewayURL string, plan applyPlan) {
+	fmt.Printf("Plan for stack %s on %s:\n", stackName, gatewayURL)
+
+	for _, name := range plan.Create {
+		fmt.Printf("  + %s (create)\n", name)
+	}
+	for _, name := range plan.Update {
+		fmt.Printf("  ~ %s (update)\n", name)
+	}
+	for _, name := range plan.Unchanged {
+		fmt.Printf("  = %s (unchanged)\n", name)
+	}
+	for _, name := range plan.Delete {
+		fmt.Printf("  - %s (delete)\n", name)
+	}
+	for _, name := range plan.Unpruned {
+		fmt.Printf("  ! %s (not in the stack file, use --prune to remove)\n", name)
+	}
+
+	fmt.Printf("\n%d to create, %d to update, %d unchanged, %d to delete.\n\n",
+		len(plan.Create), len(plan.Update), len(plan.Unchanged), len(plan.Delete))
+}
+
+// executeApplyPlan runs the plan, stopping early if the command is interrupted
+func executeApplyPlan(ctx context.Context, client *proxy.Client, plan applyPlan, desired map[string]*proxy.DeployFunctionSpec) error {
+	var failedFunctions []string
+
+	for _, name := range append(append([]string{}, plan.Create...), plan.Update...) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		fmt.Printf("Deploying: %s.\n", name)
+
+		spec := desired[name]
+		spec.Update = !containsString(plan.Create, name)
+		if err := deployFunction(ctx, client, spec); err != nil && ctx.Err() == nil {
+			fmt.Printf("Unable to deploy %s: %s\n\n", name, err)
+			failedFunctions = append(failedFunctions, name)
+		}
+	}
+
+	for _, name := range plan.Delete {
+		if ctx.Err() != nil {
+			break
+		}
+
+		fmt.Printf("Deleting: %s.\n", name)
+		if err := deleteFunction(ctx, client, name); err != nil && ctx.Err() == nil {
+			failedFunctions = append(failedFunctions, name)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("apply interrupted, run apply again to finish the plan: %s", ctx.Err())
+	}
+
+	if len(failedFunctions) > 0 {
+		return fmt.Errorf("failed to apply %d function(s): %s", len(failedFunctions), strings.Join(failedFunctions, ", "))
+	}
+
+	return nil
+}
+
+// hashDeploySpec hashes the fields of a spec which end up on the gateway
+func hashDeploySpec(spec *proxy.DeployFunctionSpec) string {
+	labels := make(map[string]string)
+	for k, v := range spec.Labels {
+		if k != specHashLabel {
+			labels[k] = v
+		}
+	}
+
+	hashed := *spec
+	hashed.Labels = labels
+	hashed.RegistryAuth = ""
+	hashed.Replace = false
+	hashed.Update = false
+
+	// Maps are marshalled with sorted keys so the output is stable
+	data, _ := json.Marshal(hashed)
+	sum := sha256.Sum256(data)
+
+	// Half of the digest fits in a label value
+	return hex.EncodeToString(sum[:16])
+}
+
+// defaultStackName derives a stack name from the file name of the YAML file
+// and a hash of its absolute path or URL, so two stack files never share a
+// name because they are in the same folder or in folders of the same name
+func defaultStackName(yamlFile string) string {
+	name, location := "", yamlFile
+	if u, err := url.Parse(yamlFile); err == nil && len(u.Scheme) > 0 && len(u.Host) > 0 {
+		name = strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	} else {
+		if abs, err := filepath.Abs(yamlFile); err == nil {
+			location = abs
+		}
+		name = strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	}
+
+	sum := sha256.Sum256([]byte(location))
+	suffix := hex.EncodeToString(sum[:4])
+
+	name = regexp.MustCompile(`[^-a-zA-Z0-9_.]+`).ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > maxLabelValueLength-len(suffix)-1 {
+		name = name[:maxLabelValueLength-len(suffix)-1]
+	}
+	name = strings.Trim(name, "-_.")
+
+	if len(name) == 0 {
+		name = "stack"
+	}
+	return name + "-" + suffix
+}
+
+func functionLabel(function requests.Function, key string) string {
+	if function.Labels == nil {
+		return ""
+	}
+	return (*function.Labels)[key]
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
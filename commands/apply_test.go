@@ -0,0 +1,211 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_makeApplyPlan(t *testing.T) {
+	specFor := func(name string, image string) *proxy.DeployFunctionSpec {
+		spec := &proxy.DeployFunctionSpec{
+			FunctionName: name,
+			Image:        image,
+			Labels:       map[string]string{stackLabel: "shop"},
+		}
+		spec.Labels[specHashLabel] = hashDeploySpec(spec)
+		return spec
+	}
+
+	deployedFrom := func(spec *proxy.DeployFunctionSpec) requests.Function {
+		labels := map[string]string{}
+		for k, v := range spec.Labels {
+			labels[k] = v
+		}
+		return requests.Function{Name: spec.FunctionName, Image: spec.Image, Labels: &labels}
+	}
+
+	unchanged := specFor("unchanged", "shop/unchanged:1")
+	changed := specFor("changed", "shop/changed:2")
+	desired := map[string]*proxy.DeployFunctionSpec{
+		"new":       specFor("new", "shop/new:1"),
+		"unchanged": unchanged,
+		"changed":   changed,
+		"adopted":   specFor("adopted", "shop/adopted:1"),
+	}
+
+	deployed := []requests.Function{
+		deployedFrom(unchanged),
+		deployedFrom(specFor("changed", "shop/changed:1")),
+		{Name: "adopted", Image: "shop/adopted:1"},
+		deployedFrom(specFor("removed", "shop/removed:1")),
+		{Name: "unowned", Image: "other/unowned:1"},
+	}
+
+	testCases := []struct {
+		name  string
+		prune bool
+		want  applyPlan
+	}{
+		{
+			name:  "without prune",
+			prune: false,
+			want: applyPlan{
+				Create:    []string{"new"},
+				Update:    []string{"adopted", "changed"},
+				Unchanged: []string{"unchanged"},
+				Unpruned:  []string{"removed"},
+			},
+		},
+		{
+			name:  "with prune",
+			prune: true,
+			want: applyPlan{
+				Create:    []string{"new"},
+				Update:    []string{"adopted", "changed"},
+				Unchanged: []string{"unchanged"},
+				Delete:    []string{"removed"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			plan := makeApplyPlan("shop", desired, deployed, testCase.prune)
+			if !reflect.DeepEqual(plan, testCase.want) {
+				t.Errorf("want plan %+v, got %+v", testCase.want, plan)
+			}
+		})
+	}
+}
+
+func Test_hashDeploySpec_IgnoresRegistryAuth(t *testing.T) {
+	spec := &proxy.DeployFunctionSpec{
+		FunctionName: "fn",
+		Image:        "fn:1",
+		EnvVars:      map[string]string{"a": "1"},
+	}
+	hash := hashDeploySpec(spec)
+
+	spec.RegistryAuth = "secret"
+	if got := hashDeploySpec(spec); got != hash {
+		t.Errorf("want hash %s to ignore registry auth, got %s", hash, got)
+	}
+
+	spec.EnvVars["a"] = "2"
+	if got := hashDeploySpec(spec); got == hash {
+		t.Errorf("want hash to change with env vars")
+	}
+}
+
+func Test_defaultStackName(t *testing.T) {
+	testCases := []struct {
+		yamlFile string
+		want     string
+	}{
+		{yamlFile: "/home/user/shop/My Shop.yml", want: `^my-shop-[0-9a-f]{8}$`},
+		{yamlFile: "https://example.com/stacks/payments.yml", want: `^payments-[0-9a-f]{8}$`},
+		{yamlFile: "/.yml", want: `^stack-[0-9a-f]{8}$`},
+	}
+
+	for _, testCase := range testCases {
+		if got := defaultStackName(testCase.yamlFile); !regexp.MustCompile(testCase.want).MatchString(got) {
+			t.Errorf("want stack name matching %s for %s, got %q", testCase.want, testCase.yamlFile, got)
+		}
+	}
+
+	if defaultStackName("/ci/job-1/app/stack.yml") != defaultStackName("/ci/job-1/app/stack.yml") {
+		t.Errorf("want the same stack name for the same file")
+	}
+
+	// Stacks in the same folder or in folders of the same name must not own
+	// each other's functions
+	names := map[string]string{}
+	for _, yamlFile := range []string{"/ci/job-1/app/stack.yml", "/ci/job-1/app/other.yml", "/ci/job-2/app/stack.yml"} {
+		name := defaultStackName(yamlFile)
+		if other, ok := names[name]; ok {
+			t.Errorf("want different stack names for %s and %s, both got %q", other, yamlFile, name)
+		}
+		names[name] = yamlFile
+	}
+}
+
+func Test_apply_CreatesAndPrunes(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-apply-test")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	stackYAML := `provider:
+  name: faas
+functions:
+  new-fn:
+    lang: dockerfile
+    image: shop/new-fn:1
+`
+	if err := ioutil.WriteFile(stackFile, []byte(stackYAML), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody: []requests.Function{
+				{Name: "old-fn", Image: "shop/old-fn:1", Labels: &map[string]string{stackLabel: "shop"}},
+				{Name: "other-fn", Image: "other/fn:1", Labels: &map[string]string{stackLabel: "other"}},
+			},
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+		{
+			Method:             http.MethodDelete,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"apply",
+			"--gateway=" + s.URL,
+			"-f=" + stackFile,
+			"--stack-name=shop",
+			"--prune",
+		})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	for _, want := range []string{`\+ new-fn \(create\)`, `- old-fn \(delete\)`, `1 to create, 0 to update, 0 unchanged, 1 to delete`, `Deployed`} {
+		if found, err := regexp.MatchString(want, stdOut); err != nil || !found {
+			t.Fatalf("Output is not as expected, want %s:\n%s", want, stdOut)
+		}
+	}
+
+	if found, _ := regexp.MatchString(`other-fn`, stdOut); found {
+		t.Fatalf("Function owned by another stack should not be in the plan:\n%s", stdOut)
+	}
+}
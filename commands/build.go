@@ -7,8 +7,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/morikuni/aec"
 	"github.com/openfaas/faas-cli/builder"
@@ -25,6 +26,10 @@ var (
 	buildArgs    []string
 	buildArgMap  map[string]string
 	buildOptions []string
+	builderName  string
+	failFast     bool
+	changedOnly  bool
+	platforms    []string
 )
 
 func init() {
@@ -35,12 +40,18 @@ func init() {
 	buildCmd.Flags().StringVar(&language, "lang", "", "Programming language template")
 
 	// Setup flags that are used only by this command (variables defined above)
-	buildCmd.Flags().BoolVar(&nocache, "no-cache", false, "Do not use Docker's build cache")
+	buildCmd.Flags().BoolVar(&nocache, "no-cache", false, "Do not use Docker's build cache and rebuild functions which are unchanged")
 	buildCmd.Flags().BoolVar(&squash, "squash", false, `Use Docker's squash flag for smaller images [experimental] `)
 	buildCmd.Flags().IntVar(&parallel, "parallel", 1, "Build in parallel to depth specified.")
-	buildCmd.Flags().BoolVar(&shrinkwrap, "shrinkwrap", false, "Just write files to ./build/ folder for shrink-wrapping")
+	buildCmd.Flags().BoolVar(&shrinkwrap, "shrinkwrap", false, "Just write files to ./build/ folder for shrink-wrapping and list the paths left out by .dockerignore and .faasignore")
 	buildCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", []string{}, "Add a build-arg for Docker (KEY=VALUE)")
 	buildCmd.Flags().StringArrayVarP(&buildOptions, "build-option", "o", []string{}, "Set a build option, e.g. dev")
+	buildCmd.Flags().BoolVar(&changedOnly, "changed-only", false, "List the functions which would be rebuilt without building them")
+	buildCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop starting new builds after the first one fails")
+	addBuilderFlag(buildCmd)
+
+	addTagFlag(buildCmd)
+	addPlatformFlag(buildCmd)
 
 	// Set bash-completion.
 	_ = buildCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
@@ -58,24 +69,46 @@ var buildCmd = &cobra.Command{
                  [--no-cache] [--squash]
                  [--regex "REGEX"]
 				 [--filter "WILDCARD"]
-				 [--parallel PARALLEL_DEPTH]
+				 [--parallel PARALLEL_DEPTH] [--fail-fast]
+				 [--changed-only]
+				 [--tag latest|sha|branch|describe|TEMPLATE]
+				 [--platform linux/amd64,linux/arm64]
 				 [--build-arg KEY=VALUE]
-				 [--build-option VALUE]`,
+				 [--build-option VALUE]
+				 [--builder cli|api]`,
 	Short: "Builds OpenFaaS function containers",
 	Long: `Builds OpenFaaS function containers either via the supplied YAML config using
 the "--yaml" flag (which may contain multiple function definitions), or directly
-via flags.`,
+via flags.
+
+Functions in a YAML config are only rebuilt when their template, handler,
+build args or build options change. A hash of these inputs is kept in
+./build/.cache and each image is also tagged with it. Use --no-cache to
+rebuild every function.`,
 	Example: `  faas-cli build -f https://domain/path/myfunctions.yml
   faas-cli build -f ./stack.yml --no-cache --build-arg NPM_VERSION=0.2.2
   faas-cli build -f ./stack.yml --build-option dev
   faas-cli build -f ./stack.yml --filter "*gif*"
   faas-cli build -f ./stack.yml --regex "fn[0-9]_.*"
+  faas-cli build -f ./stack.yml --builder api
+  faas-cli build -f ./stack.yml --parallel 4 --fail-fast
+  faas-cli build -f ./stack.yml --changed-only
+  faas-cli build -f ./stack.yml --tag sha
+  faas-cli build -f ./stack.yml --platform linux/amd64,linux/arm64
   faas-cli build --image=my_image --lang=python --handler=/path/to/fn/ 
                  --name=my_fn --squash`,
 	PreRunE: preRunBuild,
 	RunE:    runBuild,
 }
 
+func addBuilderFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&builderName, "builder", builder.CLIBackend, `Image builder, "cli" runs the docker CLI and "api" calls the Docker Engine API at DOCKER_HOST`)
+}
+
+func addPlatformFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&platforms, "platform", []string{}, "Platforms for a multi-arch image such as linux/amd64,linux/arm64, overrides the platforms in the YAML file")
+}
+
 // preRunBuild validates args & flags
 func preRunBuild(cmd *cobra.Command, args []string) error {
 	language, _ = validateLanguageFlag(language)
@@ -124,78 +157,291 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
 
+		if err := applyTagFormat(parsedServices, tagFormat); err != nil {
+			return err
+		}
+
 		if parsedServices != nil {
 			services = *parsedServices
 		}
 	}
 
-	if pullErr := PullTemplates(DefaultTemplateRepository); pullErr != nil {
+	// Listing what changed only reads, so templates are not pulled for it
+	if changedOnly {
+		if len(services.Functions) == 0 {
+			return fmt.Errorf("--changed-only needs a YAML file with --yaml/-f")
+		}
+		return printChanged(services.Functions)
+	}
+
+	if pullErr := pullBuildTemplates(services.Templates); pullErr != nil {
 		return fmt.Errorf("could not pull templates for OpenFaaS: %v", pullErr)
 	}
 
+	backend, err := builder.NewBackend(builderName)
+	if err != nil {
+		return err
+	}
+
 	if len(services.Functions) > 0 {
 
-		build(&services, parallel, shrinkwrap)
+		return build(&services, backend, parallel, shrinkwrap, failFast)
+
+	}
+
+	if len(image) == 0 {
+		return fmt.Errorf("please provide a valid --image name for your Docker image")
+	}
+	if len(handler) == 0 {
+		return fmt.Errorf("please provide the full path to your function's handler")
+	}
+	if len(functionName) == 0 {
+		return fmt.Errorf("please provide the deployed --name of your function")
+	}
+	_, err = builder.BuildImage(backend, builder.FunctionBuild{
+		ImageBuild:   builder.ImageBuild{Image: image, NoCache: nocache, Squash: squash, BuildArgs: buildArgMap},
+		FunctionName: functionName,
+		Handler:      handler,
+		Language:     language,
+		BuildOptions: buildOptions,
+		Shrinkwrap:   shrinkwrap,
+	})
+	return err
+}
 
-	} else {
-		if len(image) == 0 {
-			return fmt.Errorf("please provide a valid --image name for your Docker image")
+// build builds the functions in parallel and prints a summary, a failed
+// build does not stop the others unless failFast is set. Functions whose
+// build hash matches the cache are not rebuilt.
+func build(services *stack.Services, backend builder.Backend, queueDepth int, shrinkwrap bool, failFast bool) error {
+	results := runFunctions(services.Functions, queueDepth, failFast, func(index int, function stack.Function) functionResult {
+		if function.SkipBuild {
+			fmt.Printf("Skipping build of: %s.\n", function.Name)
+			return functionResult{Status: resultSkipped}
 		}
-		if len(handler) == 0 {
-			return fmt.Errorf("please provide the full path to your function's handler")
+
+		fmt.Printf(aec.YellowF.Apply("[%d] > Building %s.\n"), index, function.Name)
+
+		if len(function.Language) == 0 {
+			err := fmt.Errorf("please provide a valid language for your function")
+			fmt.Printf(aec.RedF.Apply("[%d] < Building %s failed: %s\n"), index, function.Name, err)
+			return functionResult{Status: resultFailed, Err: err}
 		}
-		if len(functionName) == 0 {
-			return fmt.Errorf("please provide the deployed --name of your function")
+
+		return buildFunction(backend, index, function, shrinkwrap)
+	})
+
+	printResults(os.Stdout, results)
+	return resultsError(results, "build")
+}
+
+// buildFunction builds the image of a function, or one image for each of its
+// platforms which each get a result
+func buildFunction(backend builder.Backend, index int, function stack.Function, shrinkwrap bool) functionResult {
+	combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
+	functionBuildArgMap := functionBuildArgs(function)
+	targets := functionPlatforms(function)
+
+	for _, platform := range targets {
+		if _, _, _, err := builder.ParsePlatform(platform); err != nil {
+			fmt.Printf(aec.RedF.Apply("[%d] < Building %s failed: %s\n"), index, function.Name, err)
+			return functionResult{Status: resultFailed, Err: err}
 		}
-		builder.BuildImage(image, handler, functionName, language, nocache, squash, shrinkwrap, buildArgMap, buildOptions)
 	}
 
-	return nil
-}
+	// A missing handler is reported by BuildImage, so build without a hash
+	buildHash, hashErr := builder.BuildHash(function.Image, function.Handler, function.Language, functionBuildArgMap, function.BuildTarget, squash, combinedBuildOptions, targets)
+	useCache := hashErr == nil && !shrinkwrap
+
+	if useCache && !nocache && builder.CachedBuildHash(function.Name) == buildHash {
+		if restoreCachedImages(backend, function.Image, buildHash, targets) {
+			fmt.Printf(aec.YellowF.Apply("[%d] < %s is unchanged, not rebuilt.\n"), index, function.Name)
+			return functionResult{Status: resultUnchanged}
+		}
+		fmt.Printf(aec.YellowF.Apply("[%d] > %s is unchanged but its image was removed, rebuilding.\n"), index, function.Name)
+	}
 
-func build(services *stack.Services, queueDepth int, shrinkwrap bool) {
-	wg := sync.WaitGroup{}
+	// The build folder is the same for every platform, so shrink-wrap once
+	if shrinkwrap || len(targets) == 0 {
+		var tags []string
+		if useCache {
+			tags = append(tags, builder.HashTag(function.Image, buildHash))
+		}
 
-	workChannel := make(chan stack.Function)
+		build := functionBuild(function, functionBuildArgMap, combinedBuildOptions)
+		build.Tags = tags
+		build.Shrinkwrap = shrinkwrap
 
-	for i := 0; i < queueDepth; i++ {
-		go func(index int) {
-			wg.Add(1)
-			for function := range workChannel {
-				fmt.Printf(aec.YellowF.Apply("[%d] > Building %s.\n"), index, function.Name)
-				if len(function.Language) == 0 {
-					fmt.Println("Please provide a valid language for your function.")
-				} else {
+		imageID, err := builder.BuildImage(backend, build)
+		if err != nil {
+			fmt.Printf(aec.RedF.Apply("[%d] < Building %s failed: %s\n"), index, function.Name, err)
+			return functionResult{Status: resultFailed, Err: err}
+		}
 
-					combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
-					builder.BuildImage(function.Image, function.Handler, function.Name, function.Language, nocache, squash, shrinkwrap, buildArgMap, combinedBuildOptions)
-				}
-				fmt.Printf(aec.YellowF.Apply("[%d] < Building %s done.\n"), index, function.Name)
-			}
+		if useCache {
+			saveBuildHash(function.Name, buildHash)
+		}
 
-			fmt.Printf(aec.YellowF.Apply("[%d] worker done.\n"), index)
-			wg.Done()
-		}(i)
+		fmt.Printf(aec.YellowF.Apply("[%d] < Building %s done.\n"), index, function.Name)
+		if shrinkwrap {
+			return functionResult{Status: resultShrinkwrapped}
+		}
+		return functionResult{Status: resultBuilt, ImageID: imageID}
 	}
 
-	for k, function := range services.Functions {
-		if function.SkipBuild {
-			fmt.Printf("Skipping build of: %s.\n", function.Name)
+	result := functionResult{Status: resultBuilt}
+	for _, platform := range targets {
+		fmt.Printf(aec.YellowF.Apply("[%d] > Building %s for %s.\n"), index, function.Name, platform)
+
+		var tags []string
+		if useCache {
+			tags = append(tags, builder.PlatformImageName(builder.HashTag(function.Image, buildHash), platform))
+		}
+
+		start := time.Now()
+		platformImage := builder.PlatformImageName(function.Image, platform)
+		build := functionBuild(function, functionBuildArgMap, combinedBuildOptions)
+		build.Image = platformImage
+		build.Tags = tags
+		build.Platform = platform
+
+		imageID, err := builder.BuildImage(backend, build)
+
+		platformResult := functionResult{Name: function.Name, Platform: platform, Status: resultBuilt, Duration: time.Since(start), ImageID: imageID}
+		if err != nil {
+			fmt.Printf(aec.RedF.Apply("[%d] < Building %s for %s failed: %s\n"), index, function.Name, platform, err)
+			platformResult.Status = resultFailed
+			platformResult.Err = err
+			result.Status = resultFailed
+		}
+		result.Platforms = append(result.Platforms, platformResult)
+	}
+
+	if result.Status == resultFailed {
+		return result
+	}
+
+	if useCache {
+		saveBuildHash(function.Name, buildHash)
+	}
+	fmt.Printf(aec.YellowF.Apply("[%d] < Building %s done.\n"), index, function.Name)
+	return result
+}
+
+func saveBuildHash(functionName string, buildHash string) {
+	if err := builder.SaveBuildHash(functionName, buildHash); err != nil {
+		fmt.Printf("Unable to save the build hash of %s: %s\n", functionName, err)
+	}
+}
+
+// functionPlatforms returns the platforms to build a function for, the
+// --platform flag takes precedence over the platforms in the YAML file
+func functionPlatforms(function stack.Function) []string {
+	if len(platforms) > 0 {
+		return platforms
+	}
+	return function.Platforms
+}
+
+// functionBuildArgs merges the build_args of a function with the --build-arg
+// flags, the flags take precedence except for ADDITIONAL_PACKAGE where the
+// packages from both are kept
+func functionBuildArgs(function stack.Function) map[string]string {
+	merged := map[string]string{}
+	for k, v := range function.BuildArgs {
+		merged[k] = v
+	}
+
+	for k, v := range buildArgMap {
+		if k == builder.AdditionalPackageBuildArg && len(merged[k]) > 0 {
+			merged[k] = merged[k] + " " + v
 		} else {
-			function.Name = k
-			workChannel <- function
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// functionBuild is the build of a function from a stack file with the
+// --no-cache and --squash flags
+func functionBuild(function stack.Function, buildArgMap map[string]string, buildOptions []string) builder.FunctionBuild {
+	return builder.FunctionBuild{
+		ImageBuild: builder.ImageBuild{
+			Image:     function.Image,
+			NoCache:   nocache,
+			Squash:    squash,
+			BuildArgs: buildArgMap,
+			Target:    function.BuildTarget,
+		},
+		FunctionName: function.Name,
+		Handler:      function.Handler,
+		Language:     function.Language,
+		BuildOptions: buildOptions,
+	}
+}
+
+// restoreCachedImages tags the image of a function, or of each of its
+// platforms, from the image tagged with the build hash. It is false when one
+// of those images was removed, so the function has to be rebuilt.
+func restoreCachedImages(backend builder.Backend, image string, buildHash string, platforms []string) bool {
+	cached := map[string]string{builder.HashTag(image, buildHash): image}
+	if len(platforms) > 0 {
+		cached = map[string]string{}
+		for _, platform := range platforms {
+			cached[builder.PlatformImageName(builder.HashTag(image, buildHash), platform)] = builder.PlatformImageName(image, platform)
+		}
+	}
+
+	for hashTag := range cached {
+		if exists, err := backend.ImageExists(hashTag); err != nil || !exists {
+			return false
+		}
+	}
+
+	// The image may have been removed or moved to another build since
+	for hashTag, target := range cached {
+		if err := backend.Tag(hashTag, target); err != nil {
+			fmt.Printf("Unable to tag %s as %s: %s\n", hashTag, target, err)
+			return false
 		}
 	}
+	return true
+}
 
-	close(workChannel)
+// printChanged lists whether each function would be rebuilt, which is when
+// its build hash differs from the one saved by its last build
+func printChanged(functions map[string]stack.Function) error {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	wg.Wait()
+	rows := [][]string{{"FUNCTION", "STATUS"}}
+	for _, name := range names {
+		function := functions[name]
 
+		status := "changed"
+		if function.SkipBuild {
+			status = resultSkipped
+		} else if !nocache {
+			combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
+			buildHash, err := builder.BuildHash(function.Image, function.Handler, function.Language, functionBuildArgs(function), function.BuildTarget, squash, combinedBuildOptions, functionPlatforms(function))
+			if err == nil && builder.CachedBuildHash(name) == buildHash {
+				status = resultUnchanged
+			}
+		}
+
+		rows = append(rows, []string{name, status})
+	}
+
+	writeTable(os.Stdout, rows)
+	return nil
 }
 
 // PullTemplates pulls templates from Github from the master zip download file.
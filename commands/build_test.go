@@ -4,7 +4,18 @@
 package commands
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
 )
 
 func Test_build(t *testing.T) {
@@ -74,3 +85,383 @@ func Test_parseBuildArgs_MultipleSeparators(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// fakeBackend records the builds and pushes it is given and fails the
+// images in fail, the images in flaky fail with a timeout that many times
+type fakeBackend struct {
+	fail  map[string]bool
+	flaky map[string]int
+
+	// removed makes every image missing from the local image store
+	removed bool
+
+	lock          sync.Mutex
+	builds        []builder.ImageBuild
+	pushes        []string
+	tags          []string
+	manifestLists map[string][]builder.PlatformImage
+}
+
+const fakeDigest = "sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba"
+
+func (b *fakeBackend) Build(build builder.ImageBuild) (string, error) {
+	b.lock.Lock()
+	b.builds = append(b.builds, build)
+	b.lock.Unlock()
+
+	if b.fail[build.Image] {
+		return "", fmt.Errorf("build of %s failed", build.Image)
+	}
+	return fakeDigest, nil
+}
+
+func (b *fakeBackend) Push(image string, registryAuth string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pushes = append(b.pushes, image)
+
+	if b.flaky[image] > 0 {
+		b.flaky[image]--
+		return "", fmt.Errorf("push of %s failed: net/http: TLS handshake timeout", image)
+	}
+	if b.fail[image] {
+		return "", fmt.Errorf("push of %s failed: denied: requested access to the resource is denied", image)
+	}
+	return fakeDigest, nil
+}
+
+func (b *fakeBackend) PushManifestList(image string, images []builder.PlatformImage, registryAuth string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.manifestLists == nil {
+		b.manifestLists = map[string][]builder.PlatformImage{}
+	}
+	b.manifestLists[image] = images
+
+	if b.fail[image] {
+		return "", fmt.Errorf("push of %s failed", image)
+	}
+	return fakeDigest, nil
+}
+
+func (b *fakeBackend) ImageExists(image string) (bool, error) {
+	return !b.removed, nil
+}
+
+func (b *fakeBackend) Tag(source string, target string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tags = append(b.tags, source+" "+target)
+	return nil
+}
+
+// dockerfileStack returns a stack of dockerfile functions and changes into a
+// folder with the dockerfile template, call the returned func to clean up
+func dockerfileStack(t *testing.T, names ...string) (*stack.Services, func()) {
+	dir, err := ioutil.TempDir("", "faas-cli-build")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	// The dockerfile language is checked for in ./template
+	if err := os.MkdirAll(filepath.Join(dir, "template", "dockerfile"), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "template", "dockerfile", "template.yml"), []byte("language: dockerfile\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range names {
+		if err := os.MkdirAll(name, 0700); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(name, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		services.Functions[name] = stack.Function{Language: "dockerfile", Handler: "./" + name, Image: "alexellis/" + name}
+	}
+
+	return services, func() {
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_build_ReportsEachFailure(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	services, cleanup := dockerfileStack(t, "figlet", "nodeinfo", "qrcode")
+	defer cleanup()
+
+	backend := &fakeBackend{fail: map[string]bool{"alexellis/figlet": true, "alexellis/qrcode": true}}
+
+	var buildErr error
+	stdOut := test.CaptureStdout(func() {
+		buildErr = build(services, backend, 2, false, false)
+	})
+
+	want := "2 of 3 function(s) failed to build:\n- figlet: build of alexellis/figlet failed\n- qrcode: build of alexellis/qrcode failed"
+	if buildErr == nil || buildErr.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, buildErr)
+	}
+
+	for _, row := range []string{
+		"FUNCTION STATUS DURATION IMAGE ID",
+		"figlet   failed",
+		"nodeinfo built",
+		"4e38e38c8ce0\n",
+		"qrcode   failed",
+	} {
+		if !strings.Contains(stdOut, row) {
+			t.Errorf("want summary to contain %q, got:\n%s", row, stdOut)
+		}
+	}
+}
+
+func Test_build_FailFast(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	services, cleanup := dockerfileStack(t, "figlet", "nodeinfo", "qrcode")
+	defer cleanup()
+
+	backend := &fakeBackend{fail: map[string]bool{"alexellis/figlet": true}}
+
+	var buildErr error
+	test.CaptureStdout(func() {
+		buildErr = build(services, backend, 1, false, true)
+	})
+
+	want := "3 of 3 function(s) failed to build:\n- figlet: build of alexellis/figlet failed\n- nodeinfo: not started after an earlier failure\n- qrcode: not started after an earlier failure"
+	if buildErr == nil || buildErr.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, buildErr)
+	}
+}
+
+func Test_build_SkipsUnchanged(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	services, cleanup := dockerfileStack(t, "figlet", "nodeinfo")
+	defer cleanup()
+
+	backend := &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+	if len(backend.builds) != 2 {
+		t.Fatalf("want both functions built, got %d builds", len(backend.builds))
+	}
+	if tags := backend.builds[0].Tags; len(tags) != 1 || !strings.HasPrefix(tags[0], "alexellis/figlet:") {
+		t.Fatalf("want figlet tagged with its build hash, got %v", tags)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join("nodeinfo", "Dockerfile"), []byte("FROM alpine:3.8\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	stdOut := test.CaptureStdout(func() {
+		printChanged(services.Functions)
+	})
+	for _, row := range []string{"figlet   unchanged", "nodeinfo changed"} {
+		if !strings.Contains(stdOut, row) {
+			t.Errorf("want report to contain %q, got:\n%s", row, stdOut)
+		}
+	}
+
+	backend = &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+	if len(backend.builds) != 1 || backend.builds[0].Image != "alexellis/nodeinfo" {
+		t.Fatalf("want only the changed function rebuilt, got %v", backend.builds)
+	}
+	if len(backend.tags) != 1 || !regexp.MustCompile(`^alexellis/figlet:[0-9a-f]{12} alexellis/figlet$`).MatchString(backend.tags[0]) {
+		t.Fatalf("want the unchanged image tagged from its build hash, got %v", backend.tags)
+	}
+
+	backend = &fakeBackend{removed: true}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+	if len(backend.builds) != 2 {
+		t.Fatalf("want both functions rebuilt when their images were removed, got %d builds", len(backend.builds))
+	}
+
+	squash = true
+	backend = &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+	if len(backend.builds) != 2 {
+		t.Fatalf("want --squash to rebuild both functions, got %d builds", len(backend.builds))
+	}
+	squash = false
+
+	nocache = true
+	backend = &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+	if len(backend.builds) != 2 {
+		t.Fatalf("want --no-cache to rebuild both functions, got %d builds", len(backend.builds))
+	}
+}
+
+func Test_functionBuildArgs(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	buildArgMap = map[string]string{
+		"GO_VERSION":                      "1.10",
+		builder.AdditionalPackageBuildArg: "git",
+	}
+	defer func() { buildArgMap = nil }()
+
+	function := stack.Function{
+		BuildArgs: map[string]string{
+			"GO_VERSION":                      "1.9",
+			"CGO_ENABLED":                     "0",
+			builder.AdditionalPackageBuildArg: "curl",
+		},
+	}
+
+	want := map[string]string{
+		"GO_VERSION":                      "1.10",
+		"CGO_ENABLED":                     "0",
+		builder.AdditionalPackageBuildArg: "curl git",
+	}
+	got := functionBuildArgs(function)
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("want build-arg %s=%s, got %q", k, v, got[k])
+		}
+	}
+}
+
+func Test_build_BuildArgsAndTarget(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	services, cleanup := dockerfileStack(t, "figlet")
+	defer cleanup()
+
+	function := services.Functions["figlet"]
+	function.BuildArgs = map[string]string{"GO_VERSION": "1.10"}
+	function.BuildTarget = "release"
+	services.Functions["figlet"] = function
+
+	backend := &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := build(services, backend, 1, false, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	if len(backend.builds) != 1 {
+		t.Fatalf("want one build, got %d", len(backend.builds))
+	}
+	if got := backend.builds[0]; got.Target != "release" || got.BuildArgs["GO_VERSION"] != "1.10" {
+		t.Fatalf("want build_args and build_target passed to the builder, got %+v", got)
+	}
+}
+
+func Test_build_MultiArch(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	services, cleanup := dockerfileStack(t, "figlet")
+	defer cleanup()
+
+	function := services.Functions["figlet"]
+	function.Platforms = []string{"linux/amd64", "linux/arm64"}
+	services.Functions["figlet"] = function
+
+	backend := &fakeBackend{fail: map[string]bool{"alexellis/figlet:latest-linux-arm64": true}}
+
+	var buildErr error
+	stdOut := test.CaptureStdout(func() {
+		buildErr = build(services, backend, 1, false, false)
+	})
+
+	want := "1 of 1 function(s) failed to build:\n- figlet (linux/arm64): build of alexellis/figlet:latest-linux-arm64 failed"
+	if buildErr == nil || buildErr.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, buildErr)
+	}
+
+	if len(backend.builds) != 2 {
+		t.Fatalf("want a build for each platform, got %d", len(backend.builds))
+	}
+	for i, platform := range function.Platforms {
+		got := backend.builds[i]
+		if got.Platform != platform || got.Image != builder.PlatformImageName("alexellis/figlet", platform) {
+			t.Errorf("want a build of %s for %s, got %s for %s", builder.PlatformImageName("alexellis/figlet", platform), platform, got.Image, got.Platform)
+		}
+	}
+
+	for _, row := range []string{
+		"FUNCTION PLATFORM    STATUS",
+		"figlet   linux/amd64 built",
+		"figlet   linux/arm64 failed",
+	} {
+		if !strings.Contains(stdOut, row) {
+			t.Errorf("want summary to contain %q, got:\n%s", row, stdOut)
+		}
+	}
+}
+
+func Test_build_ChangedOnlyDoesNotPullTemplates(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	_, cleanup := dockerfileStack(t, "figlet")
+	defer cleanup()
+
+	stackYAML := `provider:
+  name: faas
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet
+templates:
+  - source: https://templates.invalid/templates.git
+    ref: 1.0.0
+`
+	if err := ioutil.WriteFile("stack.yml", []byte(stackYAML), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"build", "-f", "stack.yml", "--changed-only"})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "figlet   changed") {
+		t.Errorf("want figlet listed as changed, got:\n%s", stdOut)
+	}
+	if _, err := os.Stat(templateLockFile); err == nil {
+		t.Errorf("want --changed-only not to pull templates")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+// defaultGatewayTimeout bounds management calls such as deploy, list and remove
+const defaultGatewayTimeout = 60 * time.Second
+
+// newProxyClient creates a gateway client which authenticates with the
+// credentials saved by "faas-cli login". The auth reference and TLS settings
+// of the active context are used when it is for the same gateway. A nil
+// timeout disables the timeout.
+func newProxyClient(gatewayURL string, timeout *time.Duration) (*proxy.Client, error) {
+	authGateway := gatewayURL
+	cliContext := contextFor(gatewayURL)
+	if cliContext != nil && len(cliContext.Auth) > 0 {
+		authGateway = cliContext.Auth
+	}
+
+	auth, err := proxy.NewCLIAuth(authGateway)
+	if err != nil {
+		return nil, err
+	}
+
+	if cliContext != nil && (len(cliContext.TLSCAFile) > 0 || cliContext.TLSInsecure) {
+		auth = proxy.WithTLSSettings(auth, cliContext.TLSCAFile, cliContext.TLSInsecure)
+	}
+
+	return proxy.NewClient(auth, gatewayURL, nil, timeout)
+}
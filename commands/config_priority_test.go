@@ -5,6 +5,8 @@ package commands
 
 import (
 	"testing"
+
+	"github.com/openfaas/faas-cli/config"
 )
 
 // Test_getGatewayURL tests for priority of URL for gateway over several sources
@@ -84,3 +86,57 @@ func Test_getGatewayURL(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// Test_getGatewayURL_Context tests where a context sits in the priority of URL for gateway
+func Test_getGatewayURL_Context(t *testing.T) {
+	defer resetForTest()
+
+	defaultValue := "http://127.0.0.1:8080"
+	testCases := []struct {
+		name        string
+		contextName string
+
+		yamlURL        string
+		argumentURL    string
+		environmentURL string
+		expectedURL    string
+	}{
+		{
+			name:        "Current context used when nothing else is provided",
+			expectedURL: "http://prod:8080",
+		},
+		{
+			name:           "Env-var overrides current context",
+			environmentURL: "http://remote-env:8080",
+			expectedURL:    "http://remote-env:8080",
+		},
+		{
+			name:        "YAML overrides current context",
+			yamlURL:     "http://remote-yml:8080",
+			expectedURL: "http://remote-yml:8080",
+		},
+		{
+			name:           "Context flag overrides YAML and env-var",
+			contextName:    "prod",
+			yamlURL:        "http://remote-yml:8080",
+			environmentURL: "http://remote-env:8080",
+			expectedURL:    "http://prod:8080",
+		},
+		{
+			name:        "Argument overrides context flag",
+			contextName: "prod",
+			argumentURL: "http://remote-arg:8080",
+			expectedURL: "http://remote-arg:8080",
+		},
+	}
+
+	for _, testCase := range testCases {
+		activeContext = &config.Context{Name: "prod", Gateway: "http://prod:8080"}
+		contextName = testCase.contextName
+
+		url := getGatewayURL(testCase.argumentURL, defaultValue, testCase.yamlURL, testCase.environmentURL)
+		if url != testCase.expectedURL {
+			t.Errorf("gatewayURL %s\nwant: %s, got: %s", testCase.name, testCase.expectedURL, url)
+		}
+	}
+}
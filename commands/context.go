@@ -0,0 +1,256 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// activeContext is the context chosen with --context or "faas-cli context
+	// use", it is nil when no context is in use
+	activeContext *config.Context
+
+	contextAdd        config.Context
+	contextListOutput string
+)
+
+func init() {
+	contextAddCmd.Flags().StringVarP(&contextAdd.Gateway, "gateway", "g", "", "Gateway URL starting with http(s)://")
+	contextAddCmd.Flags().StringVar(&contextAdd.Network, "network", "", "Default network for functions deployed with this context")
+	contextAddCmd.Flags().StringVarP(&contextAdd.ImagePrefix, "prefix", "p", "", "Default image prefix for \"faas-cli new\"")
+	contextAddCmd.Flags().StringVar(&contextAdd.Auth, "auth", "", "Use the credentials saved by \"faas-cli login\" for this gateway URL instead of --gateway")
+	contextAddCmd.Flags().StringVar(&contextAdd.TLSCAFile, "tls-ca", "", "CA bundle used to verify the gateway (PEM)")
+	contextAddCmd.Flags().BoolVar(&contextAdd.TLSInsecure, "tls-no-verify", false, "Skip verification of the gateway TLS certificate")
+	addOutputFlag(contextListCmd, &contextListOutput)
+
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+	faasCmd.AddCommand(contextCmd)
+}
+
+var contextCmd = &cobra.Command{
+	Use:   `context`,
+	Short: "Manage named gateways",
+	Long: `Contexts save a gateway URL with its default network, image prefix,
+credentials and TLS settings so you can switch between environments such as
+dev, staging and prod.
+
+The gateway is chosen in this order:
+  1. --gateway
+  2. --context
+  3. the gateway in the stack file
+  4. OPENFAAS_URL
+  5. the current context, set with "faas-cli context use"
+  6. ` + defaultGateway,
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:   `add NAME --gateway GATEWAY_URL [--network NETWORK] [--prefix PREFIX] [--auth GATEWAY_URL] [--tls-ca CA_FILE] [--tls-no-verify]`,
+	Short: "Add or replace a context",
+	Example: `  faas-cli context add dev --gateway http://127.0.0.1:8080
+  faas-cli context add prod --gateway https://gateway.example.com --prefix registry.example.com/team --tls-ca ca.pem`,
+	RunE: runContextAdd,
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:     `use NAME`,
+	Short:   "Set the current context",
+	Example: `  faas-cli context use prod`,
+	RunE:    runContextUse,
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   `list [--output table|wide|json|yaml|go-template=TEMPLATE]`,
+	Short: "List the saved contexts",
+	Example: `  faas-cli context list
+  faas-cli context list --output json`,
+	RunE: runContextList,
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:     `delete NAME`,
+	Short:   "Delete a context",
+	Example: `  faas-cli context delete staging`,
+	RunE:    runContextDelete,
+}
+
+// contextDescription is the record printed by "faas-cli context list"
+type contextDescription struct {
+	Name        string `json:"name" yaml:"name"`
+	Current     bool   `json:"current" yaml:"current"`
+	Gateway     string `json:"gateway" yaml:"gateway"`
+	Network     string `json:"network" yaml:"network"`
+	ImagePrefix string `json:"imagePrefix" yaml:"imagePrefix"`
+	Auth        string `json:"auth" yaml:"auth"`
+	TLSCAFile   string `json:"tlsCAFile" yaml:"tlsCAFile"`
+	TLSInsecure bool   `json:"tlsInsecure" yaml:"tlsInsecure"`
+}
+
+func runContextAdd(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the name of the context to add")
+	}
+
+	if len(contextAdd.Gateway) == 0 {
+		return fmt.Errorf("give the gateway of the context with --gateway")
+	}
+
+	cliContext := contextAdd
+	cliContext.Name = args[0]
+	cliContext.Gateway = normalizeGatewayURL(cliContext.Gateway)
+	if len(cliContext.Auth) > 0 {
+		cliContext.Auth = normalizeGatewayURL(cliContext.Auth)
+	}
+
+	if err := config.SaveContext(cliContext); err != nil {
+		return err
+	}
+
+	fmt.Printf("Context %s saved for %s\n", cliContext.Name, cliContext.Gateway)
+	return nil
+}
+
+func runContextUse(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the name of the context to use")
+	}
+
+	if err := config.UseContext(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Using context %s\n", args[0])
+	return nil
+}
+
+func runContextList(cmd *cobra.Command, args []string) error {
+	format, err := parseOutputFormat(contextListOutput)
+	if err != nil {
+		return err
+	}
+
+	contexts, current, err := config.ListContexts()
+	if err != nil {
+		return err
+	}
+
+	if len(contexts) == 0 && format.isText() {
+		fmt.Println("No contexts, add one with \"faas-cli context add\"")
+		return nil
+	}
+
+	descriptions := []contextDescription{}
+	for _, cliContext := range contexts {
+		descriptions = append(descriptions, contextDescription{
+			Name:        cliContext.Name,
+			Current:     cliContext.Name == current,
+			Gateway:     cliContext.Gateway,
+			Network:     cliContext.Network,
+			ImagePrefix: cliContext.ImagePrefix,
+			Auth:        cliContext.Auth,
+			TLSCAFile:   cliContext.TLSCAFile,
+			TLSInsecure: cliContext.TLSInsecure,
+		})
+	}
+
+	return format.print(os.Stdout, descriptions, func(w io.Writer, wide bool) {
+		writeTable(w, contextRows(descriptions, wide))
+	})
+}
+
+func contextRows(descriptions []contextDescription, wide bool) [][]string {
+	header := []string{"Current", "Name", "Gateway"}
+	if wide {
+		header = append(header, "Network", "Image prefix", "Auth", "TLS")
+	}
+
+	rows := [][]string{header}
+	for _, description := range descriptions {
+		current := ""
+		if description.Current {
+			current = "*"
+		}
+
+		row := []string{current, description.Name, description.Gateway}
+		if wide {
+			tls := "verify"
+			if description.TLSInsecure {
+				tls = "no-verify"
+			} else if len(description.TLSCAFile) > 0 {
+				tls = description.TLSCAFile
+			}
+			row = append(row, description.Network, description.ImagePrefix, description.Auth, tls)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func runContextDelete(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the name of the context to delete")
+	}
+
+	if err := config.RemoveContext(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Context %s deleted\n", args[0])
+	return nil
+}
+
+// loadContext sets activeContext before any command runs. Only a context
+// named by --context has to exist, a saved current context which was deleted
+// is cleared with a warning, and the context commands skip it altogether so
+// they can repair a broken config.
+func loadContext(cmd *cobra.Command, args []string) error {
+	if cmd == contextCmd || cmd.Parent() == contextCmd {
+		return nil
+	}
+
+	if len(contextName) > 0 {
+		cliContext, err := config.LookupContext(contextName)
+		if err != nil {
+			return err
+		}
+		activeContext = cliContext
+		return nil
+	}
+
+	cliContext, missing, err := config.LookupCurrentContext()
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: the current context %s no longer exists and has been cleared, see \"faas-cli context list\"\n", missing)
+	}
+
+	activeContext = cliContext
+	return nil
+}
+
+// contextFor returns the active context when it is for gatewayURL
+func contextFor(gatewayURL string) *config.Context {
+	if activeContext == nil || normalizeGatewayURL(activeContext.Gateway) != gatewayURL {
+		return nil
+	}
+	return activeContext
+}
+
+// contextNetwork returns the network of the active context or the default
+func contextNetwork() string {
+	if activeContext != nil && len(activeContext.Network) > 0 {
+		return activeContext.Network
+	}
+	return defaultNetwork
+}
@@ -0,0 +1,151 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_context_AddUseList(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-context")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	config.DefaultDir = dir
+	config.DefaultFile = "context-test.yml"
+
+	for _, args := range [][]string{
+		{"context", "add", "dev", "--gateway", "http://127.0.0.1:8080/"},
+		{"context", "add", "prod", "--gateway", "https://Gateway.example.com", "--prefix", "registry.example.com/team"},
+		{"context", "use", "prod"},
+	} {
+		test.CaptureStdout(func() {
+			faasCmd.SetArgs(args)
+			if err := faasCmd.Execute(); err != nil {
+				t.Fatalf("Error returned: %s", err)
+			}
+		})
+	}
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"context", "list"})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	want := `Current Name Gateway
+        dev  http://127.0.0.1:8080
+*       prod https://gateway.example.com
+`
+	if stdOut != want {
+		t.Fatalf("want contexts:\n%s\ngot:\n%s", want, stdOut)
+	}
+
+	if err := loadContext(listCmd, nil); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if activeContext == nil || activeContext.ImagePrefix != "registry.example.com/team" {
+		t.Fatalf("want the current context to be loaded, got %+v", activeContext)
+	}
+}
+
+func Test_context_FlagSelectsGateway(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-context")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	config.DefaultDir = dir
+	config.DefaultFile = "context-test.yml"
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []requests.Function{{Name: "function-test-1", Image: "image-test-1", Replicas: 1}},
+		},
+	})
+	defer s.Close()
+
+	if err := config.SaveContext(config.Context{Name: "staging", Gateway: s.URL}); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"list", "--context", "staging", "--gateway", defaultGateway})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	if found, err := regexp.MatchString(`(?m:function-test-1)`, stdOut); err != nil || !found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+
+	faasCmd.SetArgs([]string{"list", "--context", "missing"})
+	if err := faasCmd.Execute(); err == nil {
+		t.Fatalf("want an error for an unknown context")
+	}
+}
+
+func Test_context_DeletedCurrentContext(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-context")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	config.DefaultDir = dir
+	config.DefaultFile = "context-test.yml"
+
+	data := []byte("contexts:\n- name: dev\n  gateway: http://127.0.0.1:8080\ncurrent_context: gone\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, config.DefaultFile), data, 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	faasCmd.SetArgs([]string{"context", "use", "dev"})
+	if err := faasCmd.Execute(); err != nil {
+		t.Fatalf("want the context commands to work with a deleted current context, got: %s", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, config.DefaultFile), data, 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if err := loadContext(listCmd, nil); err != nil {
+		t.Fatalf("want a warning for a deleted current context, got: %s", err)
+	}
+	if activeContext != nil {
+		t.Fatalf("want no active context, got %+v", activeContext)
+	}
+
+	if _, current, err := config.ListContexts(); err != nil || len(current) > 0 {
+		t.Fatalf("want the current context to be cleared, got %q, %v", current, err)
+	}
+
+	contextName = "gone"
+	if err := loadContext(listCmd, nil); err == nil {
+		t.Fatalf("want an error for a deleted context given with --context")
+	}
+}
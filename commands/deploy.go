@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -11,11 +12,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/stack"
@@ -31,6 +34,7 @@ type DeployFlags struct {
 	secrets          []string
 	labelOpts        []string
 	sendRegistryAuth bool
+	useDigests       bool
 }
 
 var deployFlags DeployFlags
@@ -56,6 +60,8 @@ func init() {
 	deployCmd.Flags().StringArrayVar(&deployFlags.constraints, "constraint", []string{}, "Apply a constraint to the function")
 	deployCmd.Flags().StringArrayVar(&deployFlags.secrets, "secret", []string{}, "Give the function access to a secure secret")
 
+	addTagFlag(deployCmd)
+	deployCmd.Flags().BoolVar(&deployFlags.useDigests, "use-digests", false, "Deploy the images by the digests saved to "+pushManifestFile+" by faas-cli push rather than by their tags")
 	deployCmd.Flags().BoolVarP(&deployFlags.sendRegistryAuth, "send-registry-auth", "a", false, "send registryAuth from Docker credentials manager with the request")
 
 	// Set bash-completion.
@@ -81,7 +87,9 @@ var deployCmd = &cobra.Command{
                   [--constraint PLACEMENT_CONSTRAINT ...]
                   [--regex "REGEX"]
                   [--filter "WILDCARD"]
-                  [--secret "SECRET_NAME"]`,
+                  [--secret "SECRET_NAME"]
+                  [--tag latest|sha|branch|describe|TEMPLATE]
+                  [--use-digests]`,
 
 	Short: "Deploy OpenFaaS functions",
 	Long: `Deploys OpenFaaS function containers either via the supplied YAML config using
@@ -94,6 +102,8 @@ via flags. Note: --replace and --update are mutually exclusive.`,
   faas-cli deploy -f ./stack.yml --regex "fn[0-9]_.*"
   faas-cli deploy -f ./stack.yml --replace=false --update=true
   faas-cli deploy -f ./stack.yml --replace=true --update=false
+  faas-cli deploy -f ./stack.yml --tag sha
+  faas-cli deploy -f ./stack.yml --use-digests
   faas-cli deploy --image=alexellis/faas-url-ping --name=url-ping
   faas-cli deploy --image=my_image --name=my_fn --handler=/path/to/fn/
                   --gateway=http://remote-site.com:8080 --lang=python
@@ -110,10 +120,13 @@ func preRunDeploy(cmd *cobra.Command, args []string) error {
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	return runDeployCommand(args, image, fprocess, functionName, deployFlags)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	return runDeployCommand(ctx, args, image, fprocess, functionName, deployFlags)
 }
 
-func runDeployCommand(args []string, image string, fprocess string, functionName string, deployFlags DeployFlags) error {
+func runDeployCommand(ctx context.Context, args []string, image string, fprocess string, functionName string, deployFlags DeployFlags) error {
 	if deployFlags.update && deployFlags.replace {
 		fmt.Println(`Cannot specify --update and --replace at the same time. One of --update or --replace must be false.
   --replace    removes an existing deployment before re-creating it
@@ -123,11 +136,21 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
 
+		if err := applyTagFormat(parsedServices, tagFormat); err != nil {
+			return err
+		}
+
+		if deployFlags.useDigests {
+			if err := applyPushDigests(parsedServices, pushManifestFile); err != nil {
+				return err
+			}
+		}
+
 		parsedServices.Provider.GatewayURL = getGatewayURL(gateway, defaultGateway, parsedServices.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
 
 		// Override network if passed
@@ -143,82 +166,60 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 	if len(services.Functions) > 0 {
 
 		if len(services.Provider.Network) == 0 {
-			services.Provider.Network = defaultNetwork
+			services.Provider.Network = contextNetwork()
+		}
+
+		timeout := defaultGatewayTimeout
+		client, err := newProxyClient(services.Provider.GatewayURL, &timeout)
+		if err != nil {
+			return err
 		}
 
+		var failedFunctions []string
+		deployStates := make(map[string]string)
 		for k, function := range services.Functions {
 
 			function.Name = k
-			fmt.Printf("Deploying: %s.\n", function.Name)
-
-			var functionConstraints []string
-			if function.Constraints != nil {
-				functionConstraints = *function.Constraints
-			} else if len(deployFlags.constraints) > 0 {
-				functionConstraints = deployFlags.constraints
-			}
-
-			if len(function.Secrets) > 0 {
-				deployFlags.secrets = mergeSlice(function.Secrets, deployFlags.secrets)
+			if ctx.Err() != nil {
+				deployStates[function.Name] = "not deployed"
+				continue
 			}
 
-			if deployFlags.sendRegistryAuth {
-
-				dockerConfig := configFile{}
-				err := readDockerConfig(&dockerConfig)
-				if err != nil {
-					log.Printf("Unable to read the docker config - %v", err.Error())
-				}
-
-				function.RegistryAuth = getRegistryAuth(&dockerConfig, function.Image)
-
-			}
+			fmt.Printf("Deploying: %s.\n", function.Name)
 
-			fileEnvironment, err := readFiles(function.EnvironmentFile)
+			spec, err := makeDeploySpec(function, services, deployFlags)
 			if err != nil {
 				return err
 			}
 
-			labelMap := map[string]string{}
-			if function.Labels != nil {
-				labelMap = *function.Labels
-			}
-
-			labelArgumentMap, labelErr := parseMap(deployFlags.labelOpts, "label")
-			if labelErr != nil {
-				return fmt.Errorf("error parsing labels: %v", labelErr)
-			}
-
-			allLabels := mergeMap(labelMap, labelArgumentMap)
-
-			allEnvironment, envErr := compileEnvironment(deployFlags.envvarOpts, function.Environment, fileEnvironment)
-			if envErr != nil {
-				return envErr
-			}
-
-			// Get FProcess to use from the ./template/template.yml, if a template is being used
-			if languageExistsNotDockerfile(function.Language) {
-				var fprocessErr error
-
-				function.FProcess, fprocessErr = deriveFprocess(function)
-				if fprocessErr != nil {
-					return fmt.Errorf(`template directory may be missing or invalid, please run "faas template pull"
-Error: %s`, fprocessErr.Error())
-				}
+			err = deployFunction(ctx, client, spec)
+			switch {
+			case err == nil:
+				deployStates[function.Name] = "deployed"
+			case ctx.Err() != nil:
+				deployStates[function.Name] = "interrupted, the state on the gateway is unknown"
+			default:
+				deployStates[function.Name] = "failed"
+				fmt.Printf("Unable to deploy %s: %s\n\n", function.Name, err)
+				failedFunctions = append(failedFunctions, function.Name)
 			}
+		}
 
-			functionResourceRequest1 := proxy.FunctionResourceRequest{
-				Limits:   function.Limits,
-				Requests: function.Requests,
-			}
+		if ctx.Err() != nil {
+			printDeployStates(deployStates)
+			return fmt.Errorf("deploy interrupted: %s", ctx.Err())
+		}
 
-			proxy.DeployFunction(function.FProcess, services.Provider.GatewayURL, function.Name, function.Image, function.RegistryAuth, function.Language, deployFlags.replace, allEnvironment, services.Provider.Network, functionConstraints, deployFlags.update, deployFlags.secrets, allLabels, functionResourceRequest1)
+		if len(failedFunctions) > 0 {
+			return fmt.Errorf("failed to deploy %d function(s): %s", len(failedFunctions), strings.Join(failedFunctions, ", "))
 		}
 	} else {
 		if len(image) == 0 || len(functionName) == 0 {
 			return fmt.Errorf("To deploy a function give --yaml/-f or a --image flag")
 		}
 
+		gateway = getGatewayURL(gateway, defaultGateway, gateway, os.Getenv(openFaaSURLEnvironment))
+
 		var registryAuth string
 		if deployFlags.sendRegistryAuth {
 			dockerConfig := configFile{}
@@ -227,11 +228,10 @@ Error: %s`, fprocessErr.Error())
 				log.Printf("Unable to read the docker config - %v\n", err.Error())
 			}
 
-			gateway = getGatewayURL(gateway, defaultGateway, gateway, os.Getenv(openFaaSURLEnvironment))
 			registryAuth = getRegistryAuth(&dockerConfig, image)
 		}
 
-		if err := deployImage(image, fprocess, functionName, registryAuth, deployFlags); err != nil {
+		if err := deployImage(ctx, image, fprocess, functionName, registryAuth, deployFlags); err != nil {
 			return err
 		}
 	}
@@ -239,8 +239,88 @@ Error: %s`, fprocessErr.Error())
 	return nil
 }
 
+// makeDeploySpec merges a function from the stack file with the deploy flags
+func makeDeploySpec(function stack.Function, services stack.Services, deployFlags DeployFlags) (*proxy.DeployFunctionSpec, error) {
+	var functionConstraints []string
+	if function.Constraints != nil {
+		functionConstraints = *function.Constraints
+	} else if len(deployFlags.constraints) > 0 {
+		functionConstraints = deployFlags.constraints
+	}
+
+	secrets := deployFlags.secrets
+	if len(function.Secrets) > 0 {
+		secrets = mergeSlice(function.Secrets, deployFlags.secrets)
+	}
+
+	if deployFlags.sendRegistryAuth {
+
+		dockerConfig := configFile{}
+		err := readDockerConfig(&dockerConfig)
+		if err != nil {
+			log.Printf("Unable to read the docker config - %v", err.Error())
+		}
+
+		function.RegistryAuth = getRegistryAuth(&dockerConfig, function.Image)
+
+	}
+
+	fileEnvironment, err := readFiles(function.EnvironmentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	labelMap := map[string]string{}
+	if function.Labels != nil {
+		labelMap = *function.Labels
+	}
+
+	labelArgumentMap, labelErr := parseMap(deployFlags.labelOpts, "label")
+	if labelErr != nil {
+		return nil, fmt.Errorf("error parsing labels: %v", labelErr)
+	}
+
+	allLabels := mergeMap(labelMap, labelArgumentMap)
+
+	allEnvironment, envErr := compileEnvironment(deployFlags.envvarOpts, function.Environment, fileEnvironment)
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	// Get FProcess to use from the ./template/template.yml, if a template is being used
+	if languageExistsNotDockerfile(function.Language) {
+		var fprocessErr error
+
+		function.FProcess, fprocessErr = deriveFprocess(function)
+		if fprocessErr != nil {
+			return nil, fmt.Errorf(`template directory may be missing or invalid, please run "faas template pull"
+Error: %s`, fprocessErr.Error())
+		}
+	}
+
+	return &proxy.DeployFunctionSpec{
+		FProcess:     function.FProcess,
+		FunctionName: function.Name,
+		Image:        function.Image,
+		RegistryAuth: function.RegistryAuth,
+		Language:     function.Language,
+		Replace:      deployFlags.replace,
+		Update:       deployFlags.update,
+		EnvVars:      allEnvironment,
+		Network:      services.Provider.Network,
+		Constraints:  functionConstraints,
+		Secrets:      secrets,
+		Labels:       allLabels,
+		FunctionResourceRequest: proxy.FunctionResourceRequest{
+			Limits:   function.Limits,
+			Requests: function.Requests,
+		},
+	}, nil
+}
+
 // deployImage deploys a function with the given image
 func deployImage(
+	ctx context.Context,
 	image string,
 	fprocess string,
 	functionName string,
@@ -260,16 +340,80 @@ func deployImage(
 		return fmt.Errorf("error parsing labels: %v", labelErr)
 	}
 
-	functionResourceRequest1 := proxy.FunctionResourceRequest{}
-	proxy.DeployFunction(fprocess, gateway, functionName,
-		image, registryAuth, language,
-		deployFlags.replace, envvars, network,
-		deployFlags.constraints, deployFlags.update, deployFlags.secrets,
-		labelMap, functionResourceRequest1)
+	functionNetwork := network
+	if len(functionNetwork) == 0 {
+		functionNetwork = contextNetwork()
+	}
+
+	spec := &proxy.DeployFunctionSpec{
+		FProcess:     fprocess,
+		FunctionName: functionName,
+		Image:        image,
+		RegistryAuth: registryAuth,
+		Language:     language,
+		Replace:      deployFlags.replace,
+		Update:       deployFlags.update,
+		EnvVars:      envvars,
+		Network:      functionNetwork,
+		Constraints:  deployFlags.constraints,
+		Secrets:      deployFlags.secrets,
+		Labels:       labelMap,
+	}
+
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(gateway, &timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := deployFunction(ctx, client, spec); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("deploy of %s interrupted, the state on the gateway is unknown: %s", functionName, ctx.Err())
+		}
+		return err
+	}
 
 	return nil
 }
 
+// deployFunction deploys a single function through the client and prints the outcome
+func deployFunction(ctx context.Context, client *proxy.Client, spec *proxy.DeployFunctionSpec) error {
+	if len(spec.RegistryAuth) > 0 && !strings.HasPrefix(client.GatewayURL, "https") {
+		fmt.Println("WARNING! Communication is not secure, please consider using HTTPS. Letsencrypt.org offers free SSL/TLS certificates.")
+	}
+
+	result, err := client.Deploy(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	if result.RollingUpdate {
+		fmt.Printf("Function %s already exists, attempting rolling-update.\n", spec.FunctionName)
+	}
+
+	fmt.Println()
+	fmt.Printf("Deployed. %s.\n", result.Status)
+	fmt.Printf("URL: %s\n\n", result.URL)
+
+	return nil
+}
+
+// printDeployStates reports what happened to each function of an interrupted deploy
+func printDeployStates(deployStates map[string]string) {
+	names := make([]string, 0, len(deployStates))
+	for name := range deployStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	fmt.Println("Deploy interrupted, function states:")
+	for _, name := range names {
+		fmt.Printf("- %s: %s\n", name, deployStates[name])
+	}
+	fmt.Println()
+}
+
 func mergeSlice(values []string, overlay []string) []string {
 	results := []string{}
 	added := make(map[string]bool)
@@ -387,6 +531,10 @@ type authConfig struct {
 type configFile struct {
 	AuthConfigs      map[string]authConfig `json:"auths"`
 	CredentialsStore string                `json:"credsStore,omitempty"`
+
+	// CredentialHelpers maps a registry to the credential helper which
+	// holds its credentials, such as "gcr.io": "gcloud"
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
 }
 
 const (
@@ -398,10 +546,49 @@ const (
 
 var (
 	configDir = os.Getenv("DOCKER_CONFIG")
+
+	// credentialHelper runs the docker-credential-<name> program
+	credentialHelper = func(name string) client.ProgramFunc {
+		return client.NewShellProgramFunc("docker-credential-" + name)
+	}
 )
 
 func readDockerConfig(config *configFile) error {
+	if err := readDockerConfigFile(config); err != nil {
+		return err
+	}
+
+	if config.CredentialsStore != "" {
+		p := credentialHelper(config.CredentialsStore)
 
+		for k := range config.AuthConfigs {
+			// A registry in credHelpers is not kept in the credsStore
+			if _, ok := config.credentialHelperFor(registryFromConfigKey(k)); ok {
+				continue
+			}
+
+			creds, err := client.Get(p, k)
+			if err != nil {
+				return err
+			}
+
+			if config.AuthConfigs[k].Auth == "" {
+				// apend base64 encoded "auth": "dGVzdDpQdXFxR3E2THZDYzhGQUwyUWtLcA==" (user:pass)
+				registryAuth := creds.Username + ":" + creds.Secret
+				registryAuth = base64.StdEncoding.EncodeToString([]byte(registryAuth))
+
+				var tmp = config.AuthConfigs[k]
+				tmp.Auth = registryAuth
+				config.AuthConfigs[k] = tmp
+			}
+		}
+	}
+	return nil
+}
+
+// readDockerConfigFile reads the Docker config file without asking the
+// credsStore for the credentials of every registry in it
+func readDockerConfigFile(config *configFile) error {
 	if configDir == "" {
 		home, err := homedir.Dir()
 		if err != nil {
@@ -425,54 +612,79 @@ func readDockerConfig(config *configFile) error {
 	if err != nil {
 		return err
 	}
-
-	if config.CredentialsStore != "" {
-		p := client.NewShellProgramFunc("docker-credential-" + config.CredentialsStore)
-
-		for k := range config.AuthConfigs {
-			creds, err := client.Get(p, k)
-			if err != nil {
-				return err
-			}
-
-			if config.AuthConfigs[k].Auth == "" {
-				// apend base64 encoded "auth": "dGVzdDpQdXFxR3E2THZDYzhGQUwyUWtLcA==" (user:pass)
-				registryAuth := creds.Username + ":" + creds.Secret
-				registryAuth = base64.StdEncoding.EncodeToString([]byte(registryAuth))
-
-				var tmp = config.AuthConfigs[k]
-				tmp.Auth = registryAuth
-				config.AuthConfigs[k] = tmp
-			}
-		}
-	}
 	return nil
 }
 
 func getRegistryAuth(config *configFile, image string) string {
+	reference, err := parseImageReference(image)
 
 	// The local library does not require an auth string.
-	if strings.Contains(image, "/") == false {
+	if err != nil || (len(reference.Registry) == 0 && len(reference.Namespace) == 0) {
 		return ""
 	}
 
-	if len(config.AuthConfigs) > 0 {
+	auth, err := config.registryAuth(reference.Registry)
+	if err != nil {
+		log.Printf("Unable to get the credentials for %s - %v\n", reference.registryName(), err)
+		return ""
+	}
+	return auth
+}
 
-		// image format is: <docker registry>/<user>/<image>
-		// so we trim <user>/<image>
-		var registry string
-		slashes := strings.Count(image, "/")
-		if slashes > 1 {
-			regS := strings.Split(image, "/")
-			registry = strings.Join(regS[:len(regS)-2], ", ")
+// registryAuth returns the base64 encoded user:password for a registry, empty
+// for the Docker Hub. A helper in credHelpers is asked first, as docker does,
+// and then the auths are searched with their keys normalised, so
+// https://index.docker.io/v1/ and docker.io are both the Docker Hub. An auth
+// without credentials is looked up in the credsStore.
+func (config *configFile) registryAuth(registry string) (string, error) {
+	if helper, ok := config.credentialHelperFor(registry); ok {
+		creds, err := client.Get(credentialHelper(helper), registryConfigKey(registry))
+		if credentials.IsErrCredentialsNotFound(err) {
+			return "", nil
+		} else if err != nil {
+			return "", err
 		}
+		return base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Secret)), nil
+	}
 
-		if registry != "" {
-			return config.AuthConfigs[registry].Auth
-		} else if (registry == "") && (config.AuthConfigs[defaultDockerRegistry].Auth != "") {
-			return config.AuthConfigs[defaultDockerRegistry].Auth
+	var keys []string
+	for key := range config.AuthConfigs {
+		if key != registryConfigKey(registry) && registryFromConfigKey(key) == registry {
+			keys = append(keys, key)
 		}
 	}
+	sort.Strings(keys)
+	keys = append([]string{registryConfigKey(registry)}, keys...)
 
-	return ""
+	for _, key := range keys {
+		entry, ok := config.AuthConfigs[key]
+		if !ok {
+			continue
+		}
+		if len(entry.Auth) > 0 {
+			return entry.Auth, nil
+		}
+
+		// The auths of a config with a credsStore only list the registries
+		if len(config.CredentialsStore) > 0 {
+			creds, err := client.Get(credentialHelper(config.CredentialsStore), key)
+			if credentials.IsErrCredentialsNotFound(err) {
+				continue
+			} else if err != nil {
+				return "", err
+			}
+			return base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Secret)), nil
+		}
+	}
+	return "", nil
+}
+
+// credentialHelperFor returns the helper in credHelpers for a registry
+func (config *configFile) credentialHelperFor(registry string) (string, bool) {
+	for key, helper := range config.CredentialHelpers {
+		if registryFromConfigKey(key) == registry {
+			return helper, true
+		}
+	}
+	return "", false
 }
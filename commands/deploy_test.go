@@ -4,10 +4,15 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"regexp"
 	"testing"
 
+	"github.com/docker/docker-credential-helpers/client"
 	"github.com/openfaas/faas-cli/test"
 )
 
@@ -115,3 +120,88 @@ func Test_getRegistryAuth_NotRequiredForLocalImage(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// fakeCredentialHelper answers "get" like a docker-credential helper with
+// the user and secret saved for each server URL
+type fakeCredentialHelper struct {
+	secrets   map[string][2]string
+	failures  map[string]string
+	serverURL string
+}
+
+func (h *fakeCredentialHelper) Input(in io.Reader) {
+	data, _ := ioutil.ReadAll(in)
+	h.serverURL = string(data)
+}
+
+func (h *fakeCredentialHelper) Output() ([]byte, error) {
+	if failure, ok := h.failures[h.serverURL]; ok {
+		return []byte(failure), errors.New("exit status 1")
+	}
+	secret, ok := h.secrets[h.serverURL]
+	if !ok {
+		return []byte("credentials not found in native keychain"), errors.New("exit status 1")
+	}
+	return json.Marshal(map[string]string{"ServerURL": h.serverURL, "Username": secret[0], "Secret": secret[1]})
+}
+
+func Test_getRegistryAuth_Registries(t *testing.T) {
+	defer func(helper func(string) client.ProgramFunc) { credentialHelper = helper }(credentialHelper)
+	credentialHelper = func(name string) client.ProgramFunc {
+		return func(args ...string) client.Program {
+			return &fakeCredentialHelper{secrets: map[string][2]string{
+				"gcr.io": {"oauth2accesstoken", "ya29.token"},
+				"123456789012.dkr.ecr.eu-west-1.amazonaws.com": {"AWS", "ecr-token"},
+			}}
+		}
+	}
+
+	config := configFile{
+		AuthConfigs: map[string]authConfig{
+			defaultDockerRegistry:                       {Auth: "hub-auth"},
+			"registry.example.com:5000":                 {Auth: "example-auth"},
+			"https://registry.internal.example.com/v2/": {Auth: "internal-auth"},
+			"ghcr.io":        {Auth: "ghcr-auth"},
+			"quay.io":        {},
+			"localhost:5000": {Auth: "local-auth"},
+		},
+		CredentialHelpers: map[string]string{
+			"gcr.io": "gcloud",
+			"123456789012.dkr.ecr.eu-west-1.amazonaws.com": "ecr-login",
+			"eu.gcr.io": "gcloud",
+		},
+	}
+
+	testCases := []struct {
+		image string
+		want  string
+	}{
+		{image: "alexellis/figlet", want: "hub-auth"},
+		{image: "alexellis/figlet:0.1", want: "hub-auth"},
+		{image: "docker.io/alexellis/figlet:0.1", want: "hub-auth"},
+		{image: "index.docker.io/alexellis/figlet", want: "hub-auth"},
+		{image: "registry-1.docker.io/alexellis/figlet", want: "hub-auth"},
+		{image: "docker.io/library/alpine:3.7", want: "hub-auth"},
+		{image: "alexellis/figlet@sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba", want: "hub-auth"},
+		{image: "registry.example.com:5000/team/app:1.0", want: "example-auth"},
+		{image: "registry.example.com:5000/app", want: "example-auth"},
+		{image: "registry.example.com/team/app:1.0", want: ""},
+		{image: "registry.internal.example.com/team/app", want: "internal-auth"},
+		{image: "ghcr.io/org/app", want: "ghcr-auth"},
+		{image: "ghcr.io/org/sub-team/app:latest", want: "ghcr-auth"},
+		{image: "quay.io/org/app", want: ""},
+		{image: "localhost:5000/figlet", want: "local-auth"},
+		{image: "gcr.io/project/app:1.0", want: "b2F1dGgyYWNjZXNzdG9rZW46eWEyOS50b2tlbg=="},
+		{image: "123456789012.dkr.ecr.eu-west-1.amazonaws.com/app:1.0", want: "QVdTOmVjci10b2tlbg=="},
+		{image: "eu.gcr.io/project/app", want: ""},
+		{image: "figlet", want: ""},
+		{image: "figlet:latest", want: ""},
+		{image: "Alexellis/Figlet", want: ""},
+	}
+
+	for _, testCase := range testCases {
+		if got := getRegistryAuth(&config, testCase.image); got != testCase.want {
+			t.Errorf("%s: want auth %q, got %q", testCase.image, testCase.want, got)
+		}
+	}
+}
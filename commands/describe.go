@@ -0,0 +1,156 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+var describeOutput string
+
+func init() {
+	describeCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	addOutputFlag(describeCmd, &describeOutput)
+
+	faasCmd.AddCommand(describeCmd)
+}
+
+var describeCmd = &cobra.Command{
+	Use:   `describe FUNCTION_NAME [--gateway GATEWAY_URL] [--output table|json|yaml|go-template=TEMPLATE]`,
+	Short: "Describe an OpenFaaS function",
+	Long:  `Shows the details of a function deployed on a local or remote gateway`,
+	Example: `  faas-cli describe figlet
+  faas-cli describe figlet --gateway https://127.0.0.1:8080
+  faas-cli describe figlet --output json`,
+	RunE: runDescribe,
+}
+
+// functionDescription is the record printed by describe
+type functionDescription struct {
+	Name              string            `json:"name" yaml:"name"`
+	Image             string            `json:"image" yaml:"image"`
+	Replicas          uint64            `json:"replicas" yaml:"replicas"`
+	AvailableReplicas uint64            `json:"availableReplicas" yaml:"availableReplicas"`
+	InvocationCount   int64             `json:"invocationCount" yaml:"invocationCount"`
+	EnvProcess        string            `json:"envProcess" yaml:"envProcess"`
+	Labels            map[string]string `json:"labels" yaml:"labels"`
+	Annotations       map[string]string `json:"annotations" yaml:"annotations"`
+	URL               string            `json:"url" yaml:"url"`
+	AsyncURL          string            `json:"asyncUrl" yaml:"asyncUrl"`
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide the name of a function to describe")
+	}
+
+	format, err := parseOutputFormat(describeOutput)
+	if err != nil {
+		return err
+	}
+
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
+		if err != nil {
+			return err
+		}
+
+		if parsedServices != nil {
+			yamlGateway = parsedServices.Provider.GatewayURL
+		}
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(gatewayAddress, &timeout)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	function, err := client.GetFunctionInfo(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	description := describeFunction(client.GatewayURL, function)
+
+	return format.print(os.Stdout, description, func(w io.Writer, wide bool) {
+		writeTable(w, describeRows(description))
+	})
+}
+
+func describeFunction(gatewayURL string, function *proxy.FunctionStatus) functionDescription {
+	description := functionDescription{
+		Name:              function.Name,
+		Image:             function.Image,
+		Replicas:          function.Replicas,
+		AvailableReplicas: function.AvailableReplicas,
+		InvocationCount:   int64(function.InvocationCount),
+		EnvProcess:        function.EnvProcess,
+		Labels:            map[string]string{},
+		Annotations:       map[string]string{},
+		URL:               gatewayURL + "/function/" + function.Name,
+		AsyncURL:          gatewayURL + "/async-function/" + function.Name,
+	}
+
+	if function.Labels != nil {
+		description.Labels = *function.Labels
+	}
+	if function.Annotations != nil {
+		description.Annotations = *function.Annotations
+	}
+
+	return description
+}
+
+func describeRows(description functionDescription) [][]string {
+	rows := [][]string{
+		{"Name:", description.Name},
+		{"Image:", description.Image},
+		{"Replicas:", strconv.FormatUint(description.Replicas, 10)},
+		{"Available replicas:", strconv.FormatUint(description.AvailableReplicas, 10)},
+		{"Invocations:", strconv.FormatInt(description.InvocationCount, 10)},
+		{"Function process:", description.EnvProcess},
+		{"URL:", description.URL},
+		{"Async URL:", description.AsyncURL},
+	}
+
+	rows = append(rows, describeMapRows("Labels:", description.Labels)...)
+	rows = append(rows, describeMapRows("Annotations:", description.Annotations)...)
+	return rows
+}
+
+func describeMapRows(title string, values map[string]string) [][]string {
+	if len(values) == 0 {
+		return [][]string{{title, "<none>"}}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var rows [][]string
+	for i, k := range keys {
+		if i == 0 {
+			rows = append(rows, []string{title, k + ": " + values[k]})
+		} else {
+			rows = append(rows, []string{"", k + ": " + values[k]})
+		}
+	}
+	return rows
+}
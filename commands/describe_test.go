@@ -0,0 +1,94 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+)
+
+var describeResponse = proxy.FunctionStatus{
+	Name:            "figlet",
+	Image:           "functions/figlet:0.1",
+	Replicas:        2,
+	InvocationCount: 10,
+	EnvProcess:      "figlet",
+	Labels:          &map[string]string{"team": "fonts"},
+}
+
+func Test_describe(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       describeResponse,
+		},
+	})
+	defer s.Close()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"describe",
+			"figlet",
+			"--gateway=" + s.URL,
+		})
+		faasCmd.Execute()
+	})
+
+	for _, want := range []string{
+		`Image:\s+functions/figlet:0.1`,
+		`Replicas:\s+2`,
+		`Invocations:\s+10`,
+		`Function process:\s+figlet`,
+		`URL:\s+` + s.URL + `/function/figlet`,
+		`Async URL:\s+` + s.URL + `/async-function/figlet`,
+		`Labels:\s+team: fonts`,
+		`Annotations:\s+<none>`,
+	} {
+		if found, err := regexp.MatchString(`(?m:`+want+`)`, stdOut); err != nil || !found {
+			t.Fatalf("Output is not as expected, want %s:\n%s", want, stdOut)
+		}
+	}
+}
+
+func Test_describe_JSON(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       describeResponse,
+		},
+	})
+	defer s.Close()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"describe",
+			"figlet",
+			"--gateway=" + s.URL,
+			"--output=json",
+		})
+		faasCmd.Execute()
+	})
+
+	var description functionDescription
+	if err := json.Unmarshal([]byte(stdOut), &description); err != nil {
+		t.Fatalf("Output is not valid JSON: %s\n%s", err, stdOut)
+	}
+
+	if description.Image != "functions/figlet:0.1" || description.AsyncURL != s.URL+"/async-function/figlet" {
+		t.Fatalf("Unexpected description: %#v", description)
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// diffContextLines is the number of unchanged lines shown around a change
+const diffContextLines = 3
+
+var diffFlags DeployFlags
+
+func init() {
+	diffCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	diffCmd.Flags().StringArrayVarP(&diffFlags.envvarOpts, "env", "e", []string{}, "Set one or more environment variables (ENVVAR=VALUE)")
+	diffCmd.Flags().StringArrayVarP(&diffFlags.labelOpts, "label", "l", []string{}, "Set one or more label (LABEL=VALUE)")
+	diffCmd.Flags().StringArrayVar(&diffFlags.constraints, "constraint", []string{}, "Apply a constraint to the function")
+	diffCmd.Flags().StringArrayVar(&diffFlags.secrets, "secret", []string{}, "Give the function access to a secure secret")
+
+	faasCmd.AddCommand(diffCmd)
+}
+
+// diffCmd shows the drift between a stack file and the deployed functions
+var diffCmd = &cobra.Command{
+	Use: `diff -f YAML_FILE [--gateway GATEWAY_URL]
+                  [--env ENVVAR=VALUE ...]
+                  [--label LABEL=VALUE ...]
+                  [--secret "SECRET_NAME"]`,
+	Short: "Show the changes a deploy would make to the deployed functions",
+	Long: `Compares each function in the YAML file with the one deployed on the gateway
+and prints a unified diff from the deployed state to the stack file.
+
+The image, fprocess and labels are always compared. Environment variables,
+secrets, constraints, limits and requests are compared when the gateway reports
+them. The command exits non-zero when there is drift.`,
+	Example: `  faas-cli diff -f ./stack.yml
+  faas-cli diff -f ./stack.yml --filter "*gif*"
+  faas-cli diff -f ./stack.yml --env stage=prod`,
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with --yaml/-f")
+	}
+
+	services, err := parseStack(stackFiles(), regex, filter)
+	if err != nil {
+		return err
+	}
+
+	services.Provider.GatewayURL = getGatewayURL(gateway, defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(services.Provider.GatewayURL, &timeout)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := client.ListStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	deployedByName := make(map[string]proxy.FunctionStatus)
+	for _, function := range deployed {
+		deployedByName[function.Name] = function
+	}
+
+	names := make([]string, 0, len(services.Functions))
+	for name := range services.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	colour := term.IsTerminal(os.Stdout.Fd())
+
+	var drifted []string
+	for _, name := range names {
+		function := services.Functions[name]
+		function.Name = name
+
+		spec, err := makeDeploySpec(function, *services, diffFlags)
+		if err != nil {
+			return err
+		}
+
+		var from []string
+		fromName := "gateway/" + name
+		status, exists := deployedByName[name]
+		if exists {
+			from = renderDeployedState(status)
+		} else {
+			fromName += " (not deployed)"
+			status = proxy.FunctionStatus{}
+		}
+		to := renderDesiredState(spec, status)
+
+		lines := unifiedDiff(fromName, "stack/"+name, from, to, diffContextLines)
+		if len(lines) == 0 {
+			continue
+		}
+
+		drifted = append(drifted, name)
+		printDiff(lines, colour)
+	}
+
+	if len(drifted) > 0 {
+		return fmt.Errorf("drift detected in %d function(s): %s", len(drifted), strings.Join(drifted, ", "))
+	}
+
+	fmt.Printf("No drift detected for %d function(s).\n", len(names))
+	return nil
+}
+
+// renderDeployedState renders the fields reported by the gateway, one per line
+func renderDeployedState(status proxy.FunctionStatus) []string {
+	lines := []string{
+		"image: " + status.Image,
+		"fprocess: " + status.EnvProcess,
+	}
+
+	labels := map[string]string{}
+	if status.Labels != nil {
+		labels = *status.Labels
+	}
+	lines = append(lines, renderMap("labels", withoutCLILabels(labels))...)
+
+	if status.EnvVars != nil {
+		lines = append(lines, renderMap("environment", *status.EnvVars)...)
+	}
+	if status.Secrets != nil {
+		lines = append(lines, renderList("secrets", *status.Secrets)...)
+	}
+	if status.Constraints != nil {
+		lines = append(lines, renderList("constraints", *status.Constraints)...)
+	}
+	if status.Limits != nil {
+		lines = append(lines, renderResources("limits", status.Limits.Memory, status.Limits.CPU)...)
+	}
+	if status.Requests != nil {
+		lines = append(lines, renderResources("requests", status.Requests.Memory, status.Requests.CPU)...)
+	}
+
+	return lines
+}
+
+// renderDesiredState renders a deploy spec with the same fields as the deployed
+// state so that fields the gateway does not report are not shown as drift. A
+// function which is not deployed has an empty status and every field is shown.
+func renderDesiredState(spec *proxy.DeployFunctionSpec, status proxy.FunctionStatus) []string {
+	deployed := len(status.Name) > 0
+
+	lines := []string{
+		"image: " + spec.Image,
+		"fprocess: " + spec.FProcess,
+	}
+	lines = append(lines, renderMap("labels", withoutCLILabels(spec.Labels))...)
+
+	if !deployed || status.EnvVars != nil {
+		lines = append(lines, renderMap("environment", spec.EnvVars)...)
+	}
+	if !deployed || status.Secrets != nil {
+		lines = append(lines, renderList("secrets", spec.Secrets)...)
+	}
+	if !deployed || status.Constraints != nil {
+		lines = append(lines, renderList("constraints", spec.Constraints)...)
+	}
+
+	resources := spec.FunctionResourceRequest
+	if !deployed || status.Limits != nil {
+		var memory, cpu string
+		if resources.Limits != nil {
+			memory, cpu = resources.Limits.Memory, resources.Limits.CPU
+		}
+		lines = append(lines, renderResources("limits", memory, cpu)...)
+	}
+	if !deployed || status.Requests != nil {
+		var memory, cpu string
+		if resources.Requests != nil {
+			memory, cpu = resources.Requests.Memory, resources.Requests.CPU
+		}
+		lines = append(lines, renderResources("requests", memory, cpu)...)
+	}
+
+	return lines
+}
+
+// withoutCLILabels drops the labels "faas-cli apply" uses for bookkeeping
+func withoutCLILabels(labels map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for k, v := range labels {
+		if k != stackLabel && k != specHashLabel {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+func renderMap(name string, values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := []string{name + ":"}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %s", k, values[k]))
+	}
+	return lines
+}
+
+func renderList(name string, values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	lines := []string{name + ":"}
+	for _, v := range sorted {
+		lines = append(lines, "  - "+v)
+	}
+	return lines
+}
+
+func renderResources(name string, memory string, cpu string) []string {
+	return []string{
+		name + ":",
+		"  memory: " + memory,
+		"  cpu: " + cpu,
+	}
+}
+
+// unifiedDiff returns the lines of a unified diff from a to b, or nothing when
+// they are equal
+func unifiedDiff(fromName string, toName string, a []string, b []string, context int) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+	}
+
+	var edits []edit
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i]})
+			changed = true
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	lines := []string{"--- " + fromName, "+++ " + toName}
+
+	// Group the edits into hunks separated by more than 2*context unchanged lines
+	for start := 0; start < len(edits); {
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+
+		hunkStart := first - context
+		if hunkStart < start {
+			hunkStart = start
+		}
+
+		hunkEnd := first
+		unchanged := 0
+		for k := first; k < len(edits); k++ {
+			if edits[k].op == ' ' {
+				unchanged++
+				if unchanged > 2*context {
+					break
+				}
+			} else {
+				unchanged = 0
+				hunkEnd = k + 1
+			}
+		}
+		hunkEnd += context
+		if hunkEnd > len(edits) {
+			hunkEnd = len(edits)
+		}
+
+		// Line numbers of the hunk in a and b are 1-based
+		aStart, bStart := 1, 1
+		for _, e := range edits[:hunkStart] {
+			if e.op != '+' {
+				aStart++
+			}
+			if e.op != '-' {
+				bStart++
+			}
+		}
+
+		aCount, bCount := 0, 0
+		var body []string
+		for _, e := range edits[hunkStart:hunkEnd] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+			body = append(body, string(e.op)+e.line)
+		}
+
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+
+		lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aCount, bStart, bCount))
+		lines = append(lines, body...)
+
+		start = hunkEnd
+	}
+
+	return lines
+}
+
+func printDiff(lines []string, colour bool) {
+	for _, line := range lines {
+		if colour {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				line = aec.Bold.Apply(line)
+			case strings.HasPrefix(line, "@@"):
+				line = aec.CyanF.Apply(line)
+			case strings.HasPrefix(line, "-"):
+				line = aec.RedF.Apply(line)
+			case strings.HasPrefix(line, "+"):
+				line = aec.GreenF.Apply(line)
+			}
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
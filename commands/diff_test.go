@@ -0,0 +1,177 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    []string
+		b    []string
+		want []string
+	}{
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: nil,
+		},
+		{
+			name: "changed line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "x", "c"},
+			want: []string{"--- from", "+++ to", "@@ -1,3 +1,3 @@", " a", "-b", "+x", " c"},
+		},
+		{
+			name: "added to empty",
+			a:    []string{},
+			b:    []string{"a"},
+			want: []string{"--- from", "+++ to", "@@ -0,0 +1,1 @@", "+a"},
+		},
+		{
+			name: "separate hunks",
+			a:    []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+			b:    []string{"x", "2", "3", "4", "5", "6", "7", "8", "9", "y"},
+			want: []string{
+				"--- from", "+++ to",
+				"@@ -1,2 +1,2 @@", "-1", "+x", " 2",
+				"@@ -9,2 +9,2 @@", " 9", "-10", "+y",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := unifiedDiff("from", "to", testCase.a, testCase.b, 1)
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("want diff %q, got %q", testCase.want, got)
+			}
+		})
+	}
+}
+
+func Test_renderDesiredState_OnlyComparesReportedFields(t *testing.T) {
+	spec := &proxy.DeployFunctionSpec{
+		Image:   "fn:1",
+		EnvVars: map[string]string{"b": "2", "a": "1"},
+		Secrets: []string{"api-key"},
+		Labels:  map[string]string{"team": "a", stackLabel: "shop"},
+	}
+	envVars := map[string]string{"a": "1", "b": "2"}
+	status := proxy.FunctionStatus{Name: "fn", Image: "fn:1", EnvVars: &envVars}
+
+	want := []string{
+		"image: fn:1",
+		"fprocess: ",
+		"labels:",
+		"  team: a",
+		"environment:",
+		"  a: 1",
+		"  b: 2",
+	}
+
+	if got := renderDesiredState(spec, status); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func Test_diff(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-diff-test")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	stackYAML := `provider:
+  name: faas
+functions:
+  fn:
+    lang: dockerfile
+    image: shop/fn:2
+    environment:
+      mode: fast
+`
+	if err := ioutil.WriteFile(stackFile, []byte(stackYAML), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	testCases := []struct {
+		name      string
+		image     string
+		wantErr   bool
+		wantLines []string
+	}{
+		{
+			name:      "drift",
+			image:     "shop/fn:1",
+			wantErr:   true,
+			wantLines: []string{`--- gateway/fn`, `\+\+\+ stack/fn`, `-image: shop/fn:1`, `\+image: shop/fn:2`},
+		},
+		{
+			name:      "no drift",
+			image:     "shop/fn:2",
+			wantErr:   false,
+			wantLines: []string{`No drift detected for 1 function\(s\)`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			envVars := map[string]string{"mode": "fast"}
+			s := test.MockHttpServer(t, []test.Request{
+				{
+					Method:             http.MethodGet,
+					Uri:                "/system/functions",
+					ResponseStatusCode: http.StatusOK,
+					ResponseBody: []proxy.FunctionStatus{
+						{Name: "fn", Image: testCase.image, EnvVars: &envVars},
+					},
+				},
+			})
+			defer s.Close()
+
+			var err error
+			stdOut := test.CaptureStdout(func() {
+				faasCmd.SetArgs([]string{
+					"diff",
+					"--gateway=" + s.URL,
+					"-f=" + stackFile,
+				})
+				err = faasCmd.Execute()
+			})
+
+			if testCase.wantErr && err == nil {
+				t.Fatalf("want error for drift")
+			} else if !testCase.wantErr && err != nil {
+				t.Fatalf("Error returned: %s", err)
+			}
+
+			for _, want := range testCase.wantLines {
+				if found, err := regexp.MatchString(`(?m:^`+want+`)`, stdOut); err != nil || !found {
+					t.Fatalf("Output is not as expected, want %s:\n%s", want, stdOut)
+				}
+			}
+
+			if found, _ := regexp.MatchString(`mode`, stdOut); found {
+				t.Fatalf("Unchanged env vars should not be in the output:\n%s", stdOut)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/term"
 	"github.com/spf13/cobra"
@@ -20,9 +21,13 @@ const (
 
 // Flags that are to be added to all commands.
 var (
-	yamlFile string
-	regex    string
-	filter   string
+	yamlFile       string
+	yamlFiles      []string
+	regex          string
+	filter         string
+	commandTimeout time.Duration
+	envSubstFiles  []string
+	contextName    string
 )
 
 // Flags that are to be added to subset of commands.
@@ -44,17 +49,44 @@ var stat = func(filename string) (os.FileInfo, error) {
 // TODO: remove this workaround once these vars are no longer global
 func resetForTest() {
 	yamlFile = ""
+	yamlFiles = nil
 	regex = ""
 	filter = ""
+	commandTimeout = 0
+	envSubstFiles = nil
+	contextName = ""
+	activeContext = nil
+	applyPrune = false
+	applyDryRun = false
+	applyStackName = ""
+	describeOutput = ""
+	listOutput = ""
+	listSortBy = ""
+	verboseList = false
+	listWatch = false
+	versionOutput = ""
+	builderName = ""
+	failFast = false
+	changedOnly = false
+	nocache = false
+	squash = false
+	tagFormat = ""
+	platforms = nil
+	pushRetries = 0
+	skipAuthCheck = false
+	deployFlags.useDigests = false
 }
 
 func init() {
 	// Setup terminal std
 	term.StdStreams()
 
-	faasCmd.PersistentFlags().StringVarP(&yamlFile, "yaml", "f", "", "Path to YAML file describing function(s)")
+	faasCmd.PersistentFlags().VarP(&yamlFileValue{}, "yaml", "f", "Path to YAML file describing function(s), repeat to merge overlays on top of it")
 	faasCmd.PersistentFlags().StringVarP(&regex, "regex", "", "", "Regex to match with function names in YAML file")
 	faasCmd.PersistentFlags().StringVarP(&filter, "filter", "", "", "Wildcard to match with function names in YAML file")
+	faasCmd.PersistentFlags().StringArrayVar(&envSubstFiles, "env-subst-file", []string{}, "File of KEY=VALUE lines used to substitute ${KEY} in the YAML file, the environment takes precedence")
+	faasCmd.PersistentFlags().StringVar(&contextName, "context", "", "Use a context saved with \"faas-cli context add\" for this command")
+	faasCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Deadline for all gateway and store requests made by the command, e.g. 30s (0 means no deadline)")
 
 	// Set Bash completion options
 	validYAMLFilenames := []string{"yaml", "yml"}
@@ -89,7 +121,8 @@ var faasCmd = &cobra.Command{
 	Short: "Manage your OpenFaaS functions from the command line",
 	Long: `
 Manage your OpenFaaS functions from the command line`,
-	Run: runFaas,
+	PersistentPreRunE: loadContext,
+	Run:               runFaas,
 }
 
 // runFaas TODO
@@ -10,6 +10,7 @@ var mockStatParams string
 
 func setupFaas(statError error) {
 	yamlFile = ""
+	yamlFiles = nil
 	mockStatParams = ""
 	faasCmd.SetOutput(ioutil.Discard)
 
@@ -19,24 +19,50 @@ const DefaultTemplateRepository = "https://github.com/openfaas/templates.git"
 
 const templateDirectory = "./template/"
 
-// fetchTemplates fetch code templates from GitHub master zip file.
+// fetchTemplates fetch code templates from a git repository, a "#" followed
+// by a tag, branch or commit pulls that ref instead of the default branch.
+// The commit pulled is recorded in template.lock.
 func fetchTemplates(templateURL string, overwrite bool) error {
 	if len(templateURL) == 0 {
 		return fmt.Errorf("pass valid templateURL")
 	}
 
+	repository, ref := splitTemplateSource(templateURL)
+	return fetchTemplatesAt(repository, ref, ref, overwrite)
+}
+
+// fetchTemplatesAt fetches the templates of a repository with checkout
+// checked out, ref is recorded in the lock as what the commit was pulled for
+func fetchTemplatesAt(repository string, ref string, checkout string, overwrite bool) error {
+	if len(repository) == 0 {
+		return fmt.Errorf("pass valid templateURL")
+	}
+	// Sources also come from stack files and template.lock
+	if err := validateTemplateSource(repository, checkout); err != nil {
+		return err
+	}
+
 	dir, err := ioutil.TempDir("", "openFaasTemplates")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	if !pullDebug {
 		defer os.RemoveAll(dir) // clean up
 	}
 
-	log.Printf("Attempting to expand templates from %s\n", templateURL)
+	log.Printf("Attempting to expand templates from %s\n", repository)
 	pullDebugPrint(fmt.Sprintf("Temp files in %s", dir))
-	args := map[string]string{"dir": dir, "repo": templateURL}
-	if err := versioncontrol.GitClone.Invoke(".", args); err != nil {
+	args := map[string]string{"dir": dir, "repo": repository, "ref": checkout}
+	if len(checkout) == 0 {
+		if err := versioncontrol.GitClone.Invoke(".", args); err != nil {
+			return err
+		}
+	} else if err := fetchTemplateRef(dir, args); err != nil {
+		return fmt.Errorf("cannot check out %s of %s: %s", checkout, repository, err)
+	}
+
+	commit, err := versioncontrol.GitHeadSHA.Output(dir, nil)
+	if err != nil {
 		return err
 	}
 
@@ -49,9 +75,37 @@ func fetchTemplates(templateURL string, overwrite bool) error {
 		log.Printf("Cannot overwrite the following %d template(s): %v\n", len(preExistingLanguages), preExistingLanguages)
 	}
 
-	log.Printf("Fetched %d template(s) : %v from %s\n", len(fetchedLanguages), fetchedLanguages, templateURL)
+	log.Printf("Fetched %d template(s) : %v from %s at %s\n", len(fetchedLanguages), fetchedLanguages, repository, shortCommit(commit))
 
-	return err
+	if len(fetchedLanguages) > 0 {
+		provided := append(append([]string{}, fetchedLanguages...), preExistingLanguages...)
+		if err := lockTemplates(templateLockFile, repository, ref, commit, fetchedLanguages, provided); err != nil {
+			return fmt.Errorf("unable to write %s: %s", templateLockFile, err)
+		}
+	}
+
+	return nil
+}
+
+// fetchTemplateRef fetches only the commit of args["ref"] into dir. A short
+// commit SHA cannot be fetched by itself, so the whole repository is cloned
+// to check those out.
+func fetchTemplateRef(dir string, args map[string]string) error {
+	if err := versioncontrol.GitInit.Invoke(".", args); err != nil {
+		return err
+	}
+	if _, err := versioncontrol.GitFetchRef.Output(dir, args); err == nil {
+		return nil
+	}
+
+	pullDebugPrint(fmt.Sprintf("Cannot fetch %s by itself, cloning all of %s", args["ref"], args["repo"]))
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := versioncontrol.GitCloneFull.Invoke(".", args); err != nil {
+		return err
+	}
+	return versioncontrol.GitCheckout.Invoke(dir, args)
 }
 
 // canWriteLanguage tells whether the language can be expanded from the zip or not.
@@ -35,6 +35,42 @@ func Test_PullTemplates(t *testing.T) {
 	})
 }
 
+func Test_fetchTemplateRef(t *testing.T) {
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+
+	commit, err := versioncontrol.GitHeadSHA.Output(localTemplateRepository, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	for _, ref := range []string{commit, commit[:7]} {
+		dir, err := ioutil.TempDir("", "openFaasTemplates")
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err := fetchTemplateRef(dir, map[string]string{"dir": dir, "repo": localTemplateRepository, "ref": ref}); err != nil {
+			t.Fatalf("Error returned for %s: %s", ref, err)
+		}
+
+		head, err := versioncontrol.GitHeadSHA.Output(dir, nil)
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+		if head != commit {
+			t.Errorf("want %s checked out for %s, got %s", commit, ref, head)
+		}
+
+		// Only a full commit SHA can be fetched without the rest of the history
+		_, err = os.Stat(filepath.Join(dir, ".git", "shallow"))
+		if shallow := err == nil; shallow != (ref == commit) {
+			t.Errorf("want a shallow fetch to be %t for %s, got %t", ref == commit, ref, shallow)
+		}
+	}
+}
+
 // setupLocalTemplateRepo will create a local copy of the core OpenFaaS templates, this
 // can be refered to as a local git repository.
 func setupLocalTemplateRepo(t *testing.T) string {
@@ -69,4 +105,6 @@ func tearDownFetchTemplates(t *testing.T) {
 	} else {
 		t.Logf("Directory template was not created: %s", err)
 	}
+
+	os.Remove(templateLockFile)
 }
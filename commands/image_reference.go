@@ -0,0 +1,143 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// registryHost matches a host name or IP address with an optional port
+	registryHost = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?$`)
+
+	// pathComponent matches one part of a repository path such as my_team
+	pathComponent = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*$`)
+
+	imageTagPattern    = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+	imageDigestPattern = regexp.MustCompile(`^[a-z0-9]+([+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+// dockerHubAliases are the hosts of the Docker Hub, an image or a Docker
+// config key with one of them is for the Docker Hub
+var dockerHubAliases = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// imageReference is an image split into its parts, for
+// registry.example.com:5000/team/app:1.0 the registry is
+// registry.example.com:5000, the namespace team and the repository app
+type imageReference struct {
+	// Registry is the host of the registry, empty for the Docker Hub
+	Registry string
+
+	// Namespace is the path between the registry and the repository, such
+	// as a user or an organisation, empty for official images
+	Namespace string
+
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageReference splits an image into its parts, the first part of the
+// path is the registry when it has a "." or a ":" or is localhost. The
+// Docker Hub is an empty registry however it is written.
+func parseImageReference(image string) (imageReference, error) {
+	reference := imageReference{}
+	name := image
+
+	if len(name) == 0 {
+		return reference, fmt.Errorf("the image is empty")
+	}
+
+	if index := strings.Index(name, "@"); index > -1 {
+		reference.Digest = name[index+1:]
+		name = name[:index]
+		if !imageDigestPattern.MatchString(reference.Digest) {
+			return reference, fmt.Errorf("invalid image %q: invalid digest %q", image, reference.Digest)
+		}
+	}
+
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		reference.Tag = name[index+1:]
+		name = name[:index]
+		if !imageTagPattern.MatchString(reference.Tag) {
+			return reference, fmt.Errorf("invalid image %q: invalid tag %q", image, reference.Tag)
+		}
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		reference.Registry = parts[0]
+		parts = parts[1:]
+		if !registryHost.MatchString(reference.Registry) {
+			return reference, fmt.Errorf("invalid image %q: invalid registry %q", image, reference.Registry)
+		}
+		if dockerHubAliases[strings.ToLower(reference.Registry)] {
+			reference.Registry = ""
+		}
+	}
+
+	for _, part := range parts {
+		if strings.ToLower(part) != part {
+			return reference, fmt.Errorf("invalid image %q: the repository must be lowercase", image)
+		}
+		if !pathComponent.MatchString(part) {
+			return reference, fmt.Errorf("invalid image %q: invalid repository %q", image, strings.Join(parts, "/"))
+		}
+	}
+
+	reference.Repository = parts[len(parts)-1]
+	reference.Namespace = strings.Join(parts[:len(parts)-1], "/")
+	return reference, nil
+}
+
+// registryName is the registry to show to a user, such as in docker login
+func (r imageReference) registryName() string {
+	if len(r.Registry) == 0 {
+		return "the Docker Hub"
+	}
+	return r.Registry
+}
+
+// registryConfigKey is the key docker login saves the credentials of a
+// registry under in the Docker config file
+func registryConfigKey(registry string) string {
+	if len(registry) == 0 {
+		return defaultDockerRegistry
+	}
+	return registry
+}
+
+// registryFromConfigKey returns the registry of a key in the auths or
+// credHelpers of the Docker config, which may be a host or a URL such as
+// https://registry.example.com:5000/v2/
+func registryFromConfigKey(key string) string {
+	host := key
+	if index := strings.Index(host, "://"); index > -1 {
+		host = host[index+3:]
+	}
+	if index := strings.Index(host, "/"); index > -1 {
+		host = host[:index]
+	}
+
+	if dockerHubAliases[strings.ToLower(host)] {
+		return ""
+	}
+	return host
+}
+
+// localRegistry is true for a registry on this machine, which usually
+// needs no credentials
+func (r imageReference) localRegistry() bool {
+	host := r.Registry
+	if index := strings.LastIndex(host, ":"); index > -1 {
+		host = host[:index]
+	}
+	return host == "localhost" || host == "127.0.0.1"
+}
@@ -0,0 +1,58 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import "testing"
+
+func Test_parseImageReference(t *testing.T) {
+	testCases := []struct {
+		image string
+		want  imageReference
+	}{
+		{image: "figlet", want: imageReference{Repository: "figlet"}},
+		{image: "alexellis/figlet:0.1", want: imageReference{Namespace: "alexellis", Repository: "figlet", Tag: "0.1"}},
+		{image: "registry.example.com:5000/team/app:1.0", want: imageReference{Registry: "registry.example.com:5000", Namespace: "team", Repository: "app", Tag: "1.0"}},
+		{image: "ghcr.io/org/sub-team/app", want: imageReference{Registry: "ghcr.io", Namespace: "org/sub-team", Repository: "app"}},
+		{image: "docker.io/alexellis/figlet", want: imageReference{Namespace: "alexellis", Repository: "figlet"}},
+		{image: "index.docker.io/library/alpine:3.7", want: imageReference{Namespace: "library", Repository: "alpine", Tag: "3.7"}},
+		{image: "localhost/figlet", want: imageReference{Registry: "localhost", Repository: "figlet"}},
+		{image: "10.1.95.201:5000/faas-cli", want: imageReference{Registry: "10.1.95.201:5000", Repository: "faas-cli"}},
+		{
+			image: "alexellis/figlet:0.1@sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba",
+			want:  imageReference{Namespace: "alexellis", Repository: "figlet", Tag: "0.1", Digest: "sha256:4e38e38c8ce0b8d9041a9c4fefe786631d1416225e13b0bfe8cfa2321aec4bba"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		got, err := parseImageReference(testCase.image)
+		if err != nil {
+			t.Errorf("%s: error returned: %s", testCase.image, err)
+			continue
+		}
+		if got != testCase.want {
+			t.Errorf("%s: want %+v, got %+v", testCase.image, testCase.want, got)
+		}
+	}
+}
+
+func Test_parseImageReference_Invalid(t *testing.T) {
+	testCases := []struct {
+		image string
+		want  string
+	}{
+		{image: "", want: "the image is empty"},
+		{image: "AlexEllis/figlet", want: `invalid image "AlexEllis/figlet": the repository must be lowercase`},
+		{image: "alexellis//figlet", want: `invalid image "alexellis//figlet": invalid repository "alexellis//figlet"`},
+		{image: "alexellis/figlet:", want: `invalid image "alexellis/figlet:": invalid tag ""`},
+		{image: "alexellis/figlet@sha256:abc", want: `invalid image "alexellis/figlet@sha256:abc": invalid digest "sha256:abc"`},
+		{image: "registry_1.example.com/figlet", want: `invalid image "registry_1.example.com/figlet": invalid registry "registry_1.example.com"`},
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseImageReference(testCase.image)
+		if err == nil || err.Error() != testCase.want {
+			t.Errorf("%s: want error %q, got %v", testCase.image, testCase.want, err)
+		}
+	}
+}
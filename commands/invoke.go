@@ -59,7 +59,7 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 	functionName = args[0]
 
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
@@ -82,14 +82,34 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unable to read standard input: %s", err.Error())
 	}
 
-	response, err := proxy.InvokeFunction(gatewayAddress, functionName, &functionInput, contentType, query, headers, invokeAsync, httpMethod)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	client, err := newProxyClient(gatewayAddress, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Invoke(ctx, &proxy.InvokeFunctionSpec{
+		FunctionName: functionName,
+		Body:         functionInput,
+		ContentType:  contentType,
+		Query:        query,
+		Headers:      headers,
+		Async:        invokeAsync,
+		Method:       httpMethod,
+	})
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("invocation of %s interrupted, the function may still be running: %s", functionName, ctx.Err())
+		}
 		return err
 	}
 
-	if response != nil {
-		os.Stdout.Write(*response)
+	if invokeAsync {
+		fmt.Fprintf(os.Stderr, "Function submitted asynchronously.\n")
 	}
 
+	os.Stdout.Write(response)
+
 	return nil
 }
@@ -5,42 +5,82 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"time"
 
-	"github.com/openfaas/faas-cli/proxy"
+	"github.com/docker/docker/pkg/term"
 	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas/gateway/requests"
 	"github.com/spf13/cobra"
 )
 
 var (
 	verboseList bool
+	listOutput  string
+	listSortBy  string
+
+	listWatch    bool
+	listInterval time.Duration
 )
 
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	listCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 
-	listCmd.Flags().BoolVarP(&verboseList, "verbose", "v", false, "Verbose output for the function list")
+	listCmd.Flags().BoolVarP(&verboseList, "verbose", "v", false, "Verbose output for the function list, same as --output wide")
+	addOutputFlag(listCmd, &listOutput)
+	listCmd.Flags().StringVar(&listSortBy, "sort-by", "", "Sort functions by name, invocations or replicas (most first)")
+	listCmd.Flags().BoolVarP(&listWatch, "watch", "w", false, "Poll the gateway and show invocations per second and replica changes until interrupted")
+	listCmd.Flags().DurationVar(&listInterval, "interval", 2*time.Second, "Interval between polls with --watch")
 
 	faasCmd.AddCommand(listCmd)
 }
 
 var listCmd = &cobra.Command{
-	Use:     `list [--gateway GATEWAY_URL] [--verbose]`,
+	Use: `list [--gateway GATEWAY_URL] [--verbose] [--output table|wide|json|yaml|go-template=TEMPLATE] [--sort-by name|invocations|replicas]
+  faas-cli list --watch [--interval 2s]`,
 	Aliases: []string{"ls"},
 	Short:   "List OpenFaaS functions",
-	Long:    `Lists OpenFaaS functions either on a local or remote gateway`,
+	Long: `Lists OpenFaaS functions either on a local or remote gateway.
+
+With --watch the table is redrawn after every interval with the invocations per
+second and functions which scaled are highlighted. When stdout is not a terminal
+a line is printed for each function which changed.`,
 	Example: `  faas-cli list
-  faas-cli list --gateway https://127.0.0.1:8080 --verbose`,
+  faas-cli list --gateway https://127.0.0.1:8080 --verbose
+  faas-cli list --output json
+  faas-cli list --sort-by invocations
+  faas-cli list --watch --interval 2s
+  faas-cli list -o go-template='{{range .}}{{.Name}} {{.Image}}{{"\n"}}{{end}}'`,
 	RunE: runList,
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	if verboseList && (listOutput == "" || listOutput == tableOutput) {
+		listOutput = wideOutput
+	}
+
+	format, err := parseOutputFormat(listOutput)
+	if err != nil {
+		return err
+	}
+
+	if err := validateSortBy(listSortBy); err != nil {
+		return err
+	}
+
+	if listWatch && !format.isText() {
+		return fmt.Errorf("--watch can only be used with the table or wide output")
+	}
+
 	var services stack.Services
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
@@ -53,26 +93,80 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	gatewayAddress = getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
 
-	functions, err := proxy.ListFunctions(gatewayAddress)
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(gatewayAddress, &timeout)
 	if err != nil {
 		return err
 	}
 
-	if verboseList {
-		fmt.Printf("%-30s\t%-40s\t%-15s\t%-5s\n", "Function", "Image", "Invocations", "Replicas")
-		for _, function := range functions {
-			functionImage := function.Image
-			if len(function.Image) > 40 {
-				functionImage = functionImage[0:38] + ".."
-			}
-			fmt.Printf("%-30s\t%-40s\t%-15d\t%-5d\n", function.Name, functionImage, int64(function.InvocationCount), function.Replicas)
-		}
-	} else {
-		fmt.Printf("%-30s\t%-15s\t%-5s\n", "Function", "Invocations", "Replicas")
-		for _, function := range functions {
-			fmt.Printf("%-30s\t%-15d\t%-5d\n", function.Name, int64(function.InvocationCount), function.Replicas)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if listWatch {
+		return watchList(ctx, client, listInterval, format.name == wideOutput, term.IsTerminal(os.Stdout.Fd()))
+	}
+
+	functions, err := client.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if functions == nil {
+		functions = []requests.Function{}
+	}
+	sortFunctions(functions, listSortBy)
+
+	return format.print(os.Stdout, functions, func(w io.Writer, wide bool) {
+		writeTable(w, functionRows(functions, wide))
+	})
+}
+
+// functionRows renders functions as table rows, wide adds the image column
+func functionRows(functions []requests.Function, wide bool) [][]string {
+	header := []string{"Function", "Invocations", "Replicas"}
+	if wide {
+		header = []string{"Function", "Image", "Invocations", "Replicas"}
+	}
 
+	rows := [][]string{header}
+	for _, function := range functions {
+		invocations := strconv.FormatInt(int64(function.InvocationCount), 10)
+		replicas := strconv.FormatUint(function.Replicas, 10)
+
+		if wide {
+			rows = append(rows, []string{function.Name, function.Image, invocations, replicas})
+		} else {
+			rows = append(rows, []string{function.Name, invocations, replicas})
 		}
 	}
-	return nil
+
+	return rows
+}
+
+func validateSortBy(sortBy string) error {
+	switch sortBy {
+	case "", "name", "invocations", "replicas":
+		return nil
+	default:
+		return fmt.Errorf("unknown --sort-by %q, use name, invocations or replicas", sortBy)
+	}
+}
+
+// sortFunctions sorts by name ascending or by invocations or replicas
+// descending, ties keep the order of the gateway
+func sortFunctions(functions []requests.Function, sortBy string) {
+	switch sortBy {
+	case "name":
+		sort.SliceStable(functions, func(i, j int) bool {
+			return functions[i].Name < functions[j].Name
+		})
+	case "invocations":
+		sort.SliceStable(functions, func(i, j int) bool {
+			return functions[i].InvocationCount > functions[j].InvocationCount
+		})
+	case "replicas":
+		sort.SliceStable(functions, func(i, j int) bool {
+			return functions[i].Replicas > functions[j].Replicas
+		})
+	}
 }
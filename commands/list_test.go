@@ -4,7 +4,9 @@
 package commands
 
 import (
+	"encoding/json"
 	"net/http"
+	"reflect"
 	"regexp"
 	"testing"
 
@@ -78,3 +80,76 @@ func Test_list_errors(t *testing.T) {
 		t.Fatal("No error found while testing missing yaml")
 	}
 }
+
+func Test_list_JSONSortedByInvocations(t *testing.T) {
+	listResponse := []requests.Function{
+		{Name: "function-test-1", Image: "image-test-1", Replicas: 1, InvocationCount: 3},
+		{Name: "function-test-2", Image: "image-test-2", Replicas: 3, InvocationCount: 999999},
+		{Name: "function-test-3", Image: "image-test-3", Replicas: 2, InvocationCount: 3},
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       listResponse,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + s.URL,
+			"--output=json",
+			"--sort-by=invocations",
+		})
+		faasCmd.Execute()
+	})
+
+	var functions []requests.Function
+	if err := json.Unmarshal([]byte(stdOut), &functions); err != nil {
+		t.Fatalf("Output is not valid JSON: %s\n%s", err, stdOut)
+	}
+
+	var names []string
+	for _, function := range functions {
+		names = append(names, function.Name)
+	}
+
+	want := []string{"function-test-2", "function-test-1", "function-test-3"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("want functions in order %v, got %v", want, names)
+	}
+}
+
+func Test_list_Wide(t *testing.T) {
+	longImage := "registry.example.com/a-very-long-organisation-name/image-test-1:latest"
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []requests.Function{{Name: "function-test-1", Image: longImage}},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + s.URL,
+			"--output=wide",
+		})
+		faasCmd.Execute()
+	})
+
+	if found, err := regexp.MatchString(`(?m:^function-test-1 +`+regexp.QuoteMeta(longImage)+` +0 +0$)`, stdOut); err != nil || !found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// clearScreen moves the cursor to the top left and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// functionDelta is the change of a function between two polls of the gateway
+type functionDelta struct {
+	Name  string
+	Image string
+
+	Invocations    int64
+	NewInvocations int64
+	// Rate is the number of invocations per second since the last poll
+	Rate float64
+
+	Replicas         uint64
+	PreviousReplicas uint64
+
+	Added   bool
+	Removed bool
+}
+
+// Scaled returns true when the replica count changed since the last poll
+func (delta functionDelta) Scaled() bool {
+	return !delta.Added && !delta.Removed && delta.Replicas != delta.PreviousReplicas
+}
+
+// Changed returns true when there is something to report for the function
+func (delta functionDelta) Changed() bool {
+	return delta.Added || delta.Removed || delta.Scaled() || delta.NewInvocations != 0
+}
+
+// watchList polls the gateway until the command is cancelled, redrawing the
+// table in place on a terminal and printing a line per change otherwise
+func watchList(ctx context.Context, client *proxy.Client, interval time.Duration, wide bool, tty bool) error {
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be greater than zero")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous []requests.Function
+	var previousPoll time.Time
+	first := true
+
+	for {
+		functions, err := client.List(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+
+		if first {
+			previous = functions
+			previousPoll = now
+		}
+		deltas := watchDeltas(previous, functions, now.Sub(previousPoll))
+
+		if tty {
+			fmt.Print(clearScreen)
+			fmt.Printf("Every %s: %s\t%s\n\n", interval, client.GatewayURL, now.Format(time.RFC1123))
+			fmt.Print(renderWatchTable(deltas, wide, true))
+		} else {
+			for _, line := range renderWatchChanges(deltas, now, first) {
+				fmt.Println(line)
+			}
+		}
+
+		previous = functions
+		previousPoll = now
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchDeltas compares two polls, the result is sorted by function name
+func watchDeltas(previous []requests.Function, current []requests.Function, elapsed time.Duration) []functionDelta {
+	previousByName := make(map[string]requests.Function)
+	for _, function := range previous {
+		previousByName[function.Name] = function
+	}
+
+	var deltas []functionDelta
+	seen := make(map[string]bool)
+	for _, function := range current {
+		seen[function.Name] = true
+
+		delta := functionDelta{
+			Name:             function.Name,
+			Image:            function.Image,
+			Invocations:      int64(function.InvocationCount),
+			Replicas:         function.Replicas,
+			PreviousReplicas: function.Replicas,
+		}
+
+		if before, ok := previousByName[function.Name]; ok {
+			delta.PreviousReplicas = before.Replicas
+			delta.NewInvocations = int64(function.InvocationCount - before.InvocationCount)
+			if elapsed > 0 {
+				delta.Rate = float64(delta.NewInvocations) / elapsed.Seconds()
+			}
+		} else {
+			delta.Added = true
+		}
+
+		deltas = append(deltas, delta)
+	}
+
+	for _, function := range previous {
+		if !seen[function.Name] {
+			deltas = append(deltas, functionDelta{
+				Name:             function.Name,
+				Image:            function.Image,
+				Invocations:      int64(function.InvocationCount),
+				PreviousReplicas: function.Replicas,
+				Removed:          true,
+			})
+		}
+	}
+
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return deltas[i].Name < deltas[j].Name
+	})
+
+	return deltas
+}
+
+// renderWatchTable renders the deltas as a table, scaled functions are
+// highlighted when colour is set
+func renderWatchTable(deltas []functionDelta, wide bool, colour bool) string {
+	header := []string{"Function", "Invocations", "Inv/s", "Replicas"}
+	if wide {
+		header = []string{"Function", "Image", "Invocations", "Inv/s", "Replicas"}
+	}
+
+	rows := [][]string{header}
+	for _, delta := range deltas {
+		if delta.Removed {
+			continue
+		}
+
+		replicas := strconv.FormatUint(delta.Replicas, 10)
+		if delta.Scaled() {
+			replicas = fmt.Sprintf("%d (was %d)", delta.Replicas, delta.PreviousReplicas)
+		}
+
+		row := []string{delta.Name}
+		if wide {
+			row = append(row, delta.Image)
+		}
+		row = append(row,
+			strconv.FormatInt(delta.Invocations, 10),
+			strconv.FormatFloat(delta.Rate, 'f', 1, 64),
+			replicas,
+		)
+		rows = append(rows, row)
+	}
+
+	var b bytes.Buffer
+	writeTable(&b, rows)
+	if !colour {
+		return b.String()
+	}
+
+	// Colour whole lines after the columns were aligned
+	lines := strings.SplitAfter(b.String(), "\n")
+	row := 1
+	for _, delta := range deltas {
+		if delta.Removed {
+			continue
+		}
+
+		switch {
+		case delta.Scaled() && delta.Replicas > delta.PreviousReplicas:
+			lines[row] = aec.GreenF.Apply(strings.TrimSuffix(lines[row], "\n")) + "\n"
+		case delta.Scaled():
+			lines[row] = aec.YellowF.Apply(strings.TrimSuffix(lines[row], "\n")) + "\n"
+		}
+		row++
+	}
+
+	return strings.Join(lines, "")
+}
+
+// renderWatchChanges renders a line for each function which changed, all
+// functions are reported on the first poll
+func renderWatchChanges(deltas []functionDelta, now time.Time, first bool) []string {
+	timestamp := now.Format(time.RFC3339)
+
+	var lines []string
+	for _, delta := range deltas {
+		switch {
+		case first:
+			lines = append(lines, fmt.Sprintf("%s %s invocations=%d replicas=%d",
+				timestamp, delta.Name, delta.Invocations, delta.Replicas))
+		case delta.Added:
+			lines = append(lines, fmt.Sprintf("%s %s added invocations=%d replicas=%d",
+				timestamp, delta.Name, delta.Invocations, delta.Replicas))
+		case delta.Removed:
+			lines = append(lines, fmt.Sprintf("%s %s removed", timestamp, delta.Name))
+		case delta.Changed():
+			line := fmt.Sprintf("%s %s invocations=%d (+%d, %.1f/s) replicas=%d",
+				timestamp, delta.Name, delta.Invocations, delta.NewInvocations, delta.Rate, delta.Replicas)
+			if delta.Scaled() {
+				line += fmt.Sprintf(" (scaled from %d)", delta.PreviousReplicas)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
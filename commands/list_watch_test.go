@@ -0,0 +1,125 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_watchDeltas(t *testing.T) {
+	previous := []requests.Function{
+		{Name: "scaled", Image: "scaled:1", InvocationCount: 10, Replicas: 1},
+		{Name: "idle", Image: "idle:1", InvocationCount: 5, Replicas: 1},
+		{Name: "removed", Image: "removed:1", InvocationCount: 1, Replicas: 1},
+	}
+	current := []requests.Function{
+		{Name: "scaled", Image: "scaled:1", InvocationCount: 30, Replicas: 3},
+		{Name: "idle", Image: "idle:1", InvocationCount: 5, Replicas: 1},
+		{Name: "added", Image: "added:1", InvocationCount: 0, Replicas: 1},
+	}
+
+	deltas := watchDeltas(previous, current, 2*time.Second)
+
+	want := []functionDelta{
+		{Name: "added", Image: "added:1", Replicas: 1, PreviousReplicas: 1, Added: true},
+		{Name: "idle", Image: "idle:1", Invocations: 5, Replicas: 1, PreviousReplicas: 1},
+		{Name: "removed", Image: "removed:1", Invocations: 1, PreviousReplicas: 1, Removed: true},
+		{Name: "scaled", Image: "scaled:1", Invocations: 30, NewInvocations: 20, Rate: 10, Replicas: 3, PreviousReplicas: 1},
+	}
+
+	if !reflect.DeepEqual(deltas, want) {
+		t.Fatalf("want deltas %+v, got %+v", want, deltas)
+	}
+}
+
+func Test_renderWatchChanges(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	deltas := []functionDelta{
+		{Name: "added", Replicas: 1, PreviousReplicas: 1, Added: true},
+		{Name: "idle", Invocations: 5, Replicas: 1, PreviousReplicas: 1},
+		{Name: "removed", Removed: true},
+		{Name: "scaled", Invocations: 30, NewInvocations: 20, Rate: 10, Replicas: 3, PreviousReplicas: 1},
+	}
+
+	want := []string{
+		"2018-03-01T12:00:00Z added added invocations=0 replicas=1",
+		"2018-03-01T12:00:00Z removed removed",
+		"2018-03-01T12:00:00Z scaled invocations=30 (+20, 10.0/s) replicas=3 (scaled from 1)",
+	}
+
+	if got := renderWatchChanges(deltas, now, false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want lines %q, got %q", want, got)
+	}
+
+	if got := renderWatchChanges(deltas, now, true); len(got) != len(deltas) {
+		t.Fatalf("want a line for every function on the first poll, got %q", got)
+	}
+}
+
+func Test_renderWatchTable(t *testing.T) {
+	deltas := []functionDelta{
+		{Name: "idle", Invocations: 5, Replicas: 1, PreviousReplicas: 1},
+		{Name: "removed", Removed: true},
+		{Name: "scaled", Invocations: 30, Rate: 10, Replicas: 3, PreviousReplicas: 1},
+	}
+
+	want := `Function Invocations Inv/s Replicas
+idle     5           0.0   1
+scaled   30          10.0  3 (was 1)
+`
+
+	if got := renderWatchTable(deltas, false, false); got != want {
+		t.Fatalf("want table:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func Test_list_WatchNotTTY(t *testing.T) {
+	var mutex sync.Mutex
+	polls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		polls++
+		invocations := float64(polls * 10)
+		mutex.Unlock()
+
+		json.NewEncoder(w).Encode([]requests.Function{
+			{Name: "function-test-1", InvocationCount: invocations, Replicas: 1},
+		})
+	}))
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + s.URL,
+			"--watch",
+			"--interval=20ms",
+			"--timeout=150ms",
+		})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	if found, err := regexp.MatchString(`(?m:function-test-1 invocations=10 replicas=1$)`, stdOut); err != nil || !found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+
+	if found, err := regexp.MatchString(`(?m:function-test-1 invocations=20 \(\+10, [0-9.]+/s\) replicas=1$)`, stdOut); err != nil || !found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/proxy"
 	"github.com/spf13/cobra"
 )
 
@@ -20,86 +22,237 @@ var (
 	username      string
 	password      string
 	passwordStdin bool
+	authType      string
+	bearerToken   string
+	clientID      string
+	clientSecret  string
+	tokenURL      string
+	scopes        []string
+	tlsCertFile   string
+	tlsKeyFile    string
+	tlsCAFile     string
 )
 
 func init() {
 	loginCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	loginCmd.Flags().StringVarP(&username, "username", "u", "", "Gateway username")
 	loginCmd.Flags().StringVarP(&password, "password", "p", "", "Gateway password")
-	loginCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Reads the gateway password from stdin")
+	loginCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Reads the gateway password, bearer token or OAuth2 client secret from stdin")
+
+	loginCmd.Flags().StringVar(&authType, "auth", "", "Authentication type: basic, bearer, oauth2 or client-cert (inferred from the other flags if not set)")
+	loginCmd.Flags().StringVar(&bearerToken, "token", "", "Static bearer token")
+	loginCmd.Flags().StringVar(&clientID, "client-id", "", "OAuth2 client ID for the client credentials grant")
+	loginCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client secret for the client credentials grant")
+	loginCmd.Flags().StringVar(&tokenURL, "token-url", "", "OAuth2 token endpoint URL")
+	loginCmd.Flags().StringArrayVar(&scopes, "scope", []string{}, "OAuth2 scope to request")
+	loginCmd.Flags().StringVar(&tlsCertFile, "tls-cert", "", "Client certificate file (PEM)")
+	loginCmd.Flags().StringVar(&tlsKeyFile, "tls-key", "", "Client certificate key file (PEM)")
+	loginCmd.Flags().StringVar(&tlsCAFile, "tls-ca", "", "CA bundle used to verify the gateway (PEM)")
 
 	faasCmd.AddCommand(loginCmd)
 }
 
 var loginCmd = &cobra.Command{
-	Use:   `login [--username USERNAME] [--password PASSWORD] [--gateway GATEWAY_URL]`,
+	Use: `login [--username USERNAME] [--password PASSWORD] [--gateway GATEWAY_URL]
+  faas-cli login --token TOKEN [--gateway GATEWAY_URL]
+  faas-cli login --client-id CLIENT_ID --client-secret SECRET --token-url URL [--scope SCOPE ...]
+  faas-cli login --tls-cert CERT_FILE --tls-key KEY_FILE [--tls-ca CA_FILE]`,
 	Short: "Log in to OpenFaaS gateway",
-	Long:  "Log in to OpenFaaS gateway.\nIf no gateway is specified, the default local one will be used.",
+	Long: `Log in to OpenFaaS gateway.
+If no gateway is specified, the default local one will be used.
+
+Basic auth, static bearer tokens, the OAuth2 client credentials grant and TLS
+client certificates are supported. OAuth2 access tokens are refreshed when they
+expire and cached in the faas-cli config file.
+
+Set "credsStore" in ~/.openfaas/config.yml to the name of a docker-credential-*
+helper, e.g. osxkeychain, secretservice, wincred or pass, to keep passwords,
+tokens and client secrets out of the config file.`,
 	Example: `  faas-cli login -u user -p password --gateway http://127.0.0.1:8080
-  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin --gateway https://openfaas.mydomain.com`,
+  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin --gateway https://openfaas.mydomain.com
+  cat ~/faas_token.txt | faas-cli login --auth bearer --password-stdin --gateway https://openfaas.mydomain.com
+  faas-cli login --client-id faas-cli --client-secret s3cr3t \
+    --token-url https://auth.mydomain.com/oauth2/token --scope openfaas
+  faas-cli login --tls-cert client.pem --tls-key client-key.pem --tls-ca ca.pem`,
 	RunE: runLogin,
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
-
-	if len(username) == 0 {
-		return fmt.Errorf("must provide --username or -u")
+	selectedAuth, err := loginAuthType()
+	if err != nil {
+		return err
 	}
 
 	if len(password) > 0 {
 		fmt.Println("WARNING! Using --password is insecure, consider using: cat ~/faas_pass.txt | faas-cli login -u user --password-stdin")
-		if passwordStdin {
-			return fmt.Errorf("--password and --password-stdin are mutually exclusive")
-		}
+	}
 
-		if len(username) == 0 {
-			return fmt.Errorf("must provide --username with --password")
-		}
+	if len(password) > 0 && passwordStdin {
+		return fmt.Errorf("--password and --password-stdin are mutually exclusive")
 	}
 
+	var stdinSecret string
 	if passwordStdin {
-		if len(username) == 0 {
-			return fmt.Errorf("must provide --username with --password-stdin")
+		if selectedAuth == config.ClientCertAuthType {
+			return fmt.Errorf("--password-stdin cannot be used with client-cert auth")
 		}
 
-		passwordStdin, err := ioutil.ReadAll(os.Stdin)
+		secretStdin, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
 
-		password = strings.TrimSpace(string(passwordStdin))
+		stdinSecret = strings.TrimSpace(string(secretStdin))
 	}
 
-	password = strings.TrimSpace(password)
-	if len(password) == 0 {
-		return fmt.Errorf("must provide a non-empty password via --password or --password-stdin")
+	gateway = getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+
+	authConfig := config.AuthConfig{
+		Gateway: gateway,
+		Auth:    selectedAuth,
 	}
 
-	fmt.Println("Calling the OpenFaaS server to validate the credentials...")
+	switch selectedAuth {
+	case config.BasicAuthType:
+		if len(username) == 0 {
+			return fmt.Errorf("must provide --username or -u")
+		}
 
-	gateway = getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+		if passwordStdin {
+			password = stdinSecret
+		}
+
+		password = strings.TrimSpace(password)
+		if len(password) == 0 {
+			return fmt.Errorf("must provide a non-empty password via --password or --password-stdin")
+		}
+
+		authConfig.Token = config.EncodeAuth(username, password)
+	case config.BearerAuthType:
+		if passwordStdin {
+			bearerToken = stdinSecret
+		}
+
+		bearerToken = strings.TrimSpace(bearerToken)
+		if len(bearerToken) == 0 {
+			return fmt.Errorf("must provide a non-empty token via --token or --password-stdin")
+		}
+
+		authConfig.Token = bearerToken
+	case config.OAuth2AuthType:
+		if passwordStdin {
+			clientSecret = stdinSecret
+		}
+
+		if len(clientID) == 0 || len(clientSecret) == 0 || len(tokenURL) == 0 {
+			return fmt.Errorf("must provide --client-id, --token-url and a client secret via --client-secret or --password-stdin")
+		}
+
+		authConfig.ClientID = clientID
+		authConfig.ClientSecret = clientSecret
+		authConfig.TokenURL = tokenURL
+		authConfig.Scopes = scopes
+	case config.ClientCertAuthType:
+		if len(tlsCertFile) == 0 || len(tlsKeyFile) == 0 {
+			return fmt.Errorf("must provide --tls-cert and --tls-key")
+		}
+
+		authConfig.CertFile = tlsCertFile
+		authConfig.KeyFile = tlsKeyFile
+		authConfig.CAFile = tlsCAFile
+	}
 
-	if err := validateLogin(gateway, username, password); err != nil {
+	auth, err := proxy.NewAuth(&authConfig)
+	if err != nil {
 		return err
 	}
 
-	if err := config.UpdateAuthConfig(gateway, username, password); err != nil {
+	// Only cache an OAuth2 token once the login has been validated
+	oauth2Auth, isOAuth2 := auth.(*proxy.OAuth2ClientCredentialsAuth)
+	if isOAuth2 {
+		oauth2Auth.OnRefresh = nil
+	}
+
+	fmt.Println("Calling the OpenFaaS server to validate the credentials...")
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := validateLogin(ctx, gateway, auth); err != nil {
 		return err
 	}
 
-	user, _, err := config.LookupAuthConfig(gateway)
-	if err != nil {
+	if isOAuth2 {
+		authConfig.Token = oauth2Auth.AccessToken
+		if !oauth2Auth.Expiry.IsZero() {
+			authConfig.TokenExpiry = oauth2Auth.Expiry.Format(time.RFC3339)
+		}
+	}
+
+	if err := config.SaveAuthConfig(authConfig); err != nil {
 		return err
 	}
-	fmt.Println("credentials saved for", user, gateway)
+
+	if selectedAuth == config.BasicAuthType {
+		user, _, err := config.LookupAuthConfig(gateway)
+		if err != nil {
+			return err
+		}
+		fmt.Println("credentials saved for", user, gateway)
+	} else {
+		fmt.Printf("credentials saved for %s (%s auth)\n", gateway, selectedAuth)
+	}
 
 	return nil
 }
 
-func validateLogin(gatewayURL string, user string, pass string) error {
+// loginAuthType returns the --auth flag or infers the auth type from the other flags
+func loginAuthType() (string, error) {
+	if len(authType) > 0 {
+		switch authType {
+		case config.BasicAuthType, config.BearerAuthType, config.OAuth2AuthType, config.ClientCertAuthType:
+			return authType, nil
+		default:
+			return "", fmt.Errorf("unknown --auth type %q, use basic, bearer, oauth2 or client-cert", authType)
+		}
+	}
+
+	var inferred []string
+	if len(username) > 0 {
+		inferred = append(inferred, config.BasicAuthType)
+	}
+	if len(bearerToken) > 0 {
+		inferred = append(inferred, config.BearerAuthType)
+	}
+	if len(clientID) > 0 || len(clientSecret) > 0 || len(tokenURL) > 0 {
+		inferred = append(inferred, config.OAuth2AuthType)
+	}
+	if len(tlsCertFile) > 0 || len(tlsKeyFile) > 0 {
+		inferred = append(inferred, config.ClientCertAuthType)
+	}
+
+	switch len(inferred) {
+	case 0:
+		return "", fmt.Errorf("must provide --username or -u")
+	case 1:
+		return inferred[0], nil
+	default:
+		return "", fmt.Errorf("flags for more than one auth type were given: %s", strings.Join(inferred, ", "))
+	}
+}
+
+func validateLogin(ctx context.Context, gatewayURL string, auth proxy.ClientAuth) error {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	if tlsAuth, ok := auth.(proxy.TLSClientAuth); ok {
+		var err error
+		if tlsConfig, err = tlsAuth.TLSConfig(); err != nil {
+			return err
+		}
+	}
+
 	tr := &http.Transport{
 		DisableKeepAlives: false,
-		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:   tlsConfig,
 	}
 	client := &http.Client{
 		Transport: tr,
@@ -110,10 +263,17 @@ func validateLogin(gatewayURL string, user string, pass string) error {
 	if err != nil {
 		return fmt.Errorf("invalid URL: %s", gatewayURL)
 	}
+	req = req.WithContext(ctx)
+
+	if err := auth.Set(req); err != nil {
+		return err
+	}
 
-	req.SetBasicAuth(user, pass)
 	res, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gatewayURL)
 	}
 
@@ -129,7 +289,10 @@ func validateLogin(gatewayURL string, user string, pass string) error {
 	case http.StatusOK:
 		return nil
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unable to login, either username or password is incorrect")
+		if _, ok := auth.(*proxy.BasicAuth); ok {
+			return fmt.Errorf("unable to login, either username or password is incorrect")
+		}
+		return fmt.Errorf("unable to login, the gateway rejected the credentials")
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
@@ -143,6 +143,9 @@ func runNewFunction(cmd *cobra.Command, args []string) error {
 
 	var imageName string
 	imagePrefix = strings.TrimSpace(imagePrefix)
+	if len(imagePrefix) == 0 && activeContext != nil {
+		imagePrefix = activeContext.ImagePrefix
+	}
 	if len(imagePrefix) > 0 {
 		imageName = imagePrefix + "/" + functionName
 	} else {
@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Values accepted by --output, go-template is followed by "=TEMPLATE"
+const (
+	tableOutput      = "table"
+	wideOutput       = "wide"
+	jsonOutput       = "json"
+	yamlOutput       = "yaml"
+	goTemplateOutput = "go-template"
+)
+
+// outputFormat prints command results in the format selected with --output
+type outputFormat struct {
+	name     string
+	template *template.Template
+}
+
+// addOutputFlag registers the --output flag shared by commands which print results
+func addOutputFlag(cmd *cobra.Command, value *string) {
+	cmd.Flags().StringVarP(value, "output", "o", tableOutput, "Output format: table, wide, json, yaml or go-template=TEMPLATE")
+}
+
+// parseOutputFormat validates the value of the --output flag
+func parseOutputFormat(value string) (*outputFormat, error) {
+	if strings.HasPrefix(value, goTemplateOutput+"=") {
+		text := strings.TrimPrefix(value, goTemplateOutput+"=")
+		if len(text) == 0 {
+			return nil, fmt.Errorf("give a template with --output %s=TEMPLATE", goTemplateOutput)
+		}
+
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %s", err)
+		}
+
+		return &outputFormat{name: goTemplateOutput, template: tmpl}, nil
+	}
+
+	switch value {
+	case "", tableOutput:
+		return &outputFormat{name: tableOutput}, nil
+	case wideOutput, jsonOutput, yamlOutput:
+		return &outputFormat{name: value}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use table, wide, json, yaml or go-template=TEMPLATE", value)
+	}
+}
+
+// isText returns true for the human readable formats
+func (format *outputFormat) isText() bool {
+	return format.name == tableOutput || format.name == wideOutput
+}
+
+// print writes data in the selected format, renderText is called for table and
+// wide output. Templates are executed against data and its field names.
+func (format *outputFormat) print(w io.Writer, data interface{}, renderText func(w io.Writer, wide bool)) error {
+	switch format.name {
+	case jsonOutput:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	case yamlOutput:
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(out))
+	case goTemplateOutput:
+		if err := format.template.Execute(w, data); err != nil {
+			return fmt.Errorf("cannot execute output template: %s", err)
+		}
+		fmt.Fprintln(w)
+	default:
+		renderText(w, format.name == wideOutput)
+	}
+
+	return nil
+}
+
+// writeTable writes tab separated rows as aligned columns
+func writeTable(w io.Writer, rows [][]string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
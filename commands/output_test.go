@@ -0,0 +1,85 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func Test_parseOutputFormat(t *testing.T) {
+	testCases := []struct {
+		value    string
+		wantName string
+		wantErr  bool
+	}{
+		{value: "", wantName: tableOutput},
+		{value: "table", wantName: tableOutput},
+		{value: "wide", wantName: wideOutput},
+		{value: "json", wantName: jsonOutput},
+		{value: "yaml", wantName: yamlOutput},
+		{value: "go-template={{.Name}}", wantName: goTemplateOutput},
+		{value: "go-template=", wantErr: true},
+		{value: "go-template={{.Name", wantErr: true},
+		{value: "xml", wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		format, err := parseOutputFormat(testCase.value)
+		if testCase.wantErr {
+			if err == nil {
+				t.Errorf("want error for %q", testCase.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Error returned for %q: %s", testCase.value, err)
+			continue
+		}
+		if format.name != testCase.wantName {
+			t.Errorf("want format %s for %q, got %s", testCase.wantName, testCase.value, format.name)
+		}
+	}
+}
+
+func Test_outputFormat_print(t *testing.T) {
+	data := []versionInfo{{Version: "0.1.0", GitCommit: "abc"}}
+	renderText := func(w io.Writer, wide bool) {
+		rows := [][]string{{"VERSION", "COMMIT"}, {"0.1.0", "abc"}}
+		if wide {
+			rows[0] = append(rows[0], "WIDE")
+			rows[1] = append(rows[1], "yes")
+		}
+		writeTable(w, rows)
+	}
+
+	testCases := []struct {
+		value string
+		want  string
+	}{
+		{value: "table", want: "VERSION COMMIT\n0.1.0   abc\n"},
+		{value: "wide", want: "VERSION COMMIT WIDE\n0.1.0   abc    yes\n"},
+		{value: "json", want: "[\n  {\n    \"version\": \"0.1.0\",\n    \"gitCommit\": \"abc\"\n  }\n]\n"},
+		{value: "yaml", want: "- version: 0.1.0\n  gitCommit: abc\n"},
+		{value: "go-template={{range .}}{{.GitCommit}}{{end}}", want: "abc\n"},
+	}
+
+	for _, testCase := range testCases {
+		format, err := parseOutputFormat(testCase.value)
+		if err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+
+		var b bytes.Buffer
+		if err := format.print(&b, data, renderText); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+
+		if b.String() != testCase.want {
+			t.Errorf("want %s output %q, got %q", testCase.value, testCase.want, b.String())
+		}
+	}
+}
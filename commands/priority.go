@@ -10,19 +10,34 @@ import (
 
 const openFaaSURLEnvironment = "OPENFAAS_URL"
 
+// getGatewayURL picks the gateway from the flag, the context given with
+// --context, the YAML file, the environment and then the current context
 func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) string {
 	var gatewayURL string
 
+	var contextURL string
+	if activeContext != nil {
+		contextURL = activeContext.Gateway
+	}
+
 	if len(argumentURL) > 0 && argumentURL != defaultURL {
 		gatewayURL = argumentURL
+	} else if len(contextName) > 0 && len(contextURL) > 0 {
+		gatewayURL = contextURL
 	} else if len(yamlURL) > 0 && yamlURL != defaultURL {
 		gatewayURL = yamlURL
 	} else if len(environmentURL) > 0 {
 		gatewayURL = environmentURL
+	} else if len(contextURL) > 0 {
+		gatewayURL = contextURL
 	} else {
 		gatewayURL = defaultURL
 	}
 
+	return normalizeGatewayURL(gatewayURL)
+}
+
+func normalizeGatewayURL(gatewayURL string) string {
 	gatewayURL = strings.ToLower(strings.TrimRight(gatewayURL, "/"))
 	if !strings.HasPrefix(gatewayURL, "http") {
 		gatewayURL = fmt.Sprintf("http://%s", gatewayURL)
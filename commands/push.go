@@ -5,8 +5,10 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/morikuni/aec"
 	"github.com/openfaas/faas-cli/builder"
@@ -18,20 +20,47 @@ func init() {
 	faasCmd.AddCommand(pushCmd)
 
 	pushCmd.Flags().IntVar(&parallel, "parallel", 1, "Push images in parallel to depth specified.")
+	pushCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop starting new pushes after the first one fails")
+	addTagFlag(pushCmd)
+	addBuilderFlag(pushCmd)
+	addPlatformFlag(pushCmd)
+	pushCmd.Flags().IntVar(&pushRetries, "retries", 3, "Retry a push which fails with a timeout or other transient error, waiting longer each time")
+	pushCmd.Flags().BoolVar(&skipAuthCheck, "skip-auth-check", false, "Push without first checking for credentials of each registry in the Docker config")
 }
 
+var (
+	pushRetries   int
+	skipAuthCheck bool
+)
+
+// pushRetryDelay is the wait before the first retry, it doubles after each
+var pushRetryDelay = 2 * time.Second
+
 // pushCmd handles pushing function container images to a remote repo
 var pushCmd = &cobra.Command{
-	Use:   `push -f YAML_FILE [--regex "REGEX"] [--filter "WILDCARD"] [--parallel]`,
+	Use: `push -f YAML_FILE [--regex "REGEX"] [--filter "WILDCARD"] [--parallel] [--fail-fast] [--tag STRATEGY]
+  [--retries N] [--skip-auth-check] [--builder cli|api] [--platform linux/amd64,linux/arm64]`,
 	Short: "Push OpenFaaS functions to remote registry (Docker Hub)",
 	Long: `Pushes the OpenFaaS function container image(s) defined in the supplied YAML
 config to a remote repository.
 
-These container images must already be present in your local image cache.`,
+These container images must already be present in your local image cache.
+
+Before pushing, each image is checked for a valid name and for credentials of
+its registry in the Docker config, and all of the problems found are listed.
+
+The digest of each pushed image is saved to push-manifest.json, which
+"faas-cli deploy --use-digests" reads to deploy exactly the images pushed.
+
+For a multi-arch function the image of each platform is pushed and then the
+image is pushed as a manifest list of them through the registry API, with the
+credentials of the registry in the Docker config.`,
 
 	Example: `  faas-cli push -f https://domain/path/myfunctions.yml
   faas-cli push -f ./stack.yml
-  faas-cli push -f ./stack.yml --parallel 4
+  faas-cli push -f ./stack.yml --parallel 4 --retries 5
+  faas-cli push -f ./stack.yml --tag sha
+  faas-cli push -f ./stack.yml --platform linux/amd64,linux/arm64
   faas-cli push -f ./stack.yml --filter "*gif*"
   faas-cli push -f ./stack.yml --regex "fn[0-9]_.*"`,
 	RunE: runPush,
@@ -41,82 +70,274 @@ func runPush(cmd *cobra.Command, args []string) error {
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
 
+		if err := applyTagFormat(parsedServices, tagFormat); err != nil {
+			return err
+		}
+
 		if parsedServices != nil {
 			services = *parsedServices
 		}
 	}
 
 	if len(services.Functions) > 0 {
-		invalidImages := validateImages(services.Functions)
-		if len(invalidImages) > 0 {
-			imageList := strings.Join(invalidImages, "\n- ")
-			return fmt.Errorf(`
-Unable to push one or more of your functions to Docker Hub:
-- ` + imageList + `
+		if problems := checkPushImages(services.Functions); len(problems) > 0 {
+			return fmt.Errorf("unable to push one or more of your functions:\n%s", strings.Join(problems, "\n"))
+		}
 
-You must provide a username or registry prefix to the Function's image such as user1/function1`)
+		backend, err := builder.NewBackend(builderName)
+		if err != nil {
+			return err
 		}
 
-		pushStack(&services, parallel)
-	} else {
-		return fmt.Errorf("you must supply a valid YAML file")
+		return pushStack(&services, backend, parallel, failFast)
 	}
-	return nil
+	return fmt.Errorf("you must supply a valid YAML file")
 }
 
-func pushImage(image string) {
-	builder.ExecCommand("./", []string{"docker", "push", image})
+// pushStack pushes the images in parallel, prints a summary and saves the
+// digests to push-manifest.json, a failed push does not stop the others
+// unless failFast is set
+func pushStack(services *stack.Services, backend builder.Backend, queueDepth int, failFast bool) error {
+	results := runFunctions(services.Functions, queueDepth, failFast, func(index int, function stack.Function) functionResult {
+		if function.SkipBuild {
+			fmt.Printf("Skipping %s\n", function.Name)
+			return functionResult{Status: resultSkipped}
+		}
+
+		fmt.Printf(aec.YellowF.Apply("[%d] > Pushing %s.\n"), index, function.Name)
+
+		if len(function.Image) == 0 {
+			err := fmt.Errorf("please provide a valid Image value in the YAML file")
+			fmt.Printf(aec.RedF.Apply("[%d] < Pushing %s failed: %s\n"), index, function.Name, err)
+			return functionResult{Status: resultFailed, Err: err}
+		}
+
+		return pushFunction(backend, index, function)
+	})
+
+	printResults(os.Stdout, results)
+
+	manifestErr := updatePushManifest(pushManifestFile, services.Functions, results)
+	if err := resultsError(results, "push"); err != nil {
+		return err
+	}
+	return manifestErr
 }
 
-func pushStack(services *stack.Services, queueDepth int) {
-	wg := sync.WaitGroup{}
-
-	workChannel := make(chan stack.Function)
-
-	for i := 0; i < queueDepth; i++ {
-		go func(index int) {
-			wg.Add(1)
-			for function := range workChannel {
-				fmt.Printf(aec.YellowF.Apply("[%d] > Pushing %s.\n"), index, function.Name)
-				if len(function.Image) == 0 {
-					fmt.Println("Please provide a valid Image value in the YAML file.")
-				} else if function.SkipBuild {
-					fmt.Printf("Skipping %s\n", function.Name)
-				} else {
-					pushImage(function.Image)
-					fmt.Printf(aec.YellowF.Apply("[%d] < Pushing %s done.\n"), index, function.Name)
-				}
-			}
+// pushFunction pushes the image of a function, or the image of each of its
+// platforms followed by the manifest list of them
+func pushFunction(backend builder.Backend, index int, function stack.Function) functionResult {
+	targets := functionPlatforms(function)
 
-			fmt.Printf(aec.YellowF.Apply("[%d] worker done.\n"), index)
-			wg.Done()
-		}(i)
+	if len(targets) == 0 {
+		digest, err := retryPush(function.Image, func() (string, error) {
+			return backend.Push(function.Image, pushRegistryAuth(function.Image))
+		})
+		if err != nil {
+			fmt.Printf(aec.RedF.Apply("[%d] < Pushing %s failed: %s\n"), index, function.Name, err)
+			return functionResult{Status: resultFailed, Err: err}
+		}
+
+		fmt.Printf(aec.YellowF.Apply("[%d] < Pushing %s done.\n"), index, function.Name)
+		return functionResult{Status: resultPushed, Digest: digest}
 	}
 
-	for k, function := range services.Functions {
-		function.Name = k
-		workChannel <- function
+	result := functionResult{Status: resultPushed}
+	var images []builder.PlatformImage
+
+	for _, platform := range targets {
+		platformImage := builder.PlatformImageName(function.Image, platform)
+		images = append(images, builder.PlatformImage{Platform: platform, Image: platformImage})
+
+		start := time.Now()
+		digest, err := retryPush(platformImage, func() (string, error) {
+			return backend.Push(platformImage, pushRegistryAuth(platformImage))
+		})
+		platformResult := functionResult{Name: function.Name, Platform: platform, Status: resultPushed, Duration: time.Since(start), Digest: digest}
+		if err != nil {
+			fmt.Printf(aec.RedF.Apply("[%d] < Pushing %s for %s failed: %s\n"), index, function.Name, platform, err)
+			platformResult.Status = resultFailed
+			platformResult.Err = err
+			result.Status = resultFailed
+		}
+		result.Platforms = append(result.Platforms, platformResult)
 	}
 
-	close(workChannel)
+	if result.Status == resultFailed {
+		return result
+	}
 
-	wg.Wait()
+	start := time.Now()
+	digest, err := retryPush(function.Image, func() (string, error) {
+		return backend.PushManifestList(function.Image, images, dockerRegistryAuth(function.Image))
+	})
+	listResult := functionResult{Name: function.Name, Platform: "manifest list", Status: resultPushed, Duration: time.Since(start), Digest: digest}
+	if err != nil {
+		fmt.Printf(aec.RedF.Apply("[%d] < Pushing the manifest list of %s failed: %s\n"), index, function.Name, err)
+		listResult.Status = resultFailed
+		listResult.Err = err
+		result.Status = resultFailed
+	} else {
+		fmt.Printf(aec.YellowF.Apply("[%d] < Pushing %s done.\n"), index, function.Name)
+		result.Digest = digest
+	}
+	result.Platforms = append(result.Platforms, listResult)
 
+	return result
 }
 
-func validateImages(functions map[string]stack.Function) []string {
-	invalidImages := []string{}
+// retryPush runs push until it succeeds, fails with an error which is not
+// transient or has been retried pushRetries times
+func retryPush(image string, push func() (string, error)) (string, error) {
+	delay := pushRetryDelay
+	for attempt := 0; ; attempt++ {
+		digest, err := push()
+		if err == nil || attempt >= pushRetries || !transientPushError(err) {
+			return digest, err
+		}
+
+		fmt.Printf("Pushing %s failed, retrying in %s: %s\n", image, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// transientPushErrors are parts of the errors given by docker and registries
+// for failures which may pass, such as a dropped connection or rate limit
+var transientPushErrors = []string{
+	"timeout",
+	"timed out",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"unexpected eof",
+	"tls handshake",
+	"too many requests",
+	"toomanyrequests",
+	"service unavailable",
+	"bad gateway",
+	"gateway timeout",
+	"internal server error",
+}
+
+func transientPushError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientPushErrors {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// pushRegistryAuth returns the credentials for an image from the Docker
+// config file when the backend does not read them itself
+func pushRegistryAuth(image string) string {
+	if builderName != builder.APIBackend {
+		return ""
+	}
+	return dockerRegistryAuth(image)
+}
+
+// dockerRegistryAuth returns the credentials for an image from the Docker
+// config file, manifest lists need them with every backend as they are
+// written straight to the registry
+func dockerRegistryAuth(image string) string {
+	dockerConfig := configFile{}
+	if err := readDockerConfigFile(&dockerConfig); err != nil {
+		fmt.Printf("Unable to read Docker credentials: %s\n", err)
+		return ""
+	}
+	return getRegistryAuth(&dockerConfig, image)
+}
+
+// validateImages returns the problem with the image of each function which
+// cannot be pushed, the Docker Hub needs a user or organisation in the name
+func validateImages(functions map[string]stack.Function) map[string]error {
+	invalidImages := map[string]error{}
 
 	for name, function := range functions {
+		if function.SkipBuild {
+			continue
+		}
 
-		if !function.SkipBuild && !strings.Contains(function.Image, `/`) {
-			invalidImages = append(invalidImages, name)
+		reference, err := parseImageReference(function.Image)
+		if err != nil {
+			invalidImages[name] = err
+		} else if len(reference.Registry) == 0 && len(reference.Namespace) == 0 {
+			invalidImages[name] = fmt.Errorf("image %q has no registry or username prefix such as user1/%s", function.Image, function.Image)
 		}
 	}
 	return invalidImages
 }
+
+// checkPushImages lists every problem which would stop a function from being
+// pushed, so they can be fixed before any push starts
+func checkPushImages(functions map[string]stack.Function) []string {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	invalidImages := validateImages(functions)
+
+	var missing []string
+	if !skipAuthCheck {
+		// Only the registries pushed to are looked up, so a broken credsStore
+		// entry for another registry is not a problem
+		dockerConfig := configFile{}
+		if err := readDockerConfigFile(&dockerConfig); err != nil && !os.IsNotExist(err) {
+			return []string{fmt.Sprintf("- unable to read the Docker credentials: %s", err)}
+		}
+		missing = missingCredentials(functions, invalidImages, &dockerConfig)
+	}
+
+	var problems []string
+	for _, name := range names {
+		if err, ok := invalidImages[name]; ok {
+			problems = append(problems, fmt.Sprintf("- %s: %s", name, err))
+		}
+	}
+	return append(problems, missing...)
+}
+
+// missingCredentials lists the functions pushed to a registry which has no
+// credentials in the Docker config, registries on this machine are left out
+func missingCredentials(functions map[string]stack.Function, invalidImages map[string]error, config *configFile) []string {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var missing []string
+	for _, name := range names {
+		function := functions[name]
+		if _, invalid := invalidImages[name]; invalid || function.SkipBuild {
+			continue
+		}
+
+		reference, err := parseImageReference(function.Image)
+		if err != nil || reference.localRegistry() {
+			continue
+		}
+
+		auth, err := config.registryAuth(reference.Registry)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("- %s: unable to get the credentials for %s: %s", name, reference.registryName(), err))
+		} else if len(auth) == 0 {
+			login := "docker login"
+			if len(reference.Registry) > 0 {
+				login += " " + reference.Registry
+			}
+			missing = append(missing, fmt.Sprintf("- %s: not logged in to %s, run %q or pass --skip-auth-check", name, reference.registryName(), login))
+		}
+	}
+	return missing
+}
@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// pushManifestFile is written by push and read by deploy --use-digests
+const pushManifestFile = "push-manifest.json"
+
+// pushManifest maps the name of each pushed function to its image and
+// digest, such as alexellis/figlet:0.1@sha256:4e38e38c...
+type pushManifest map[string]string
+
+// readPushManifest reads a push manifest, a missing file has no functions
+func readPushManifest(path string) (pushManifest, error) {
+	manifest := pushManifest{}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", path, err)
+	}
+	return manifest, nil
+}
+
+// updatePushManifest records the digest of each function pushed, the
+// functions which were not pushed keep the digest of their last push
+func updatePushManifest(path string, functions map[string]stack.Function, results []functionResult) error {
+	manifest, err := readPushManifest(path)
+	if err != nil {
+		return err
+	}
+
+	updated := false
+	for _, result := range results {
+		if result.Status != resultPushed || len(result.Digest) == 0 {
+			continue
+		}
+		manifest[result.Name] = functions[result.Name].Image + "@" + result.Digest
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("unable to write %s: %s", path, err)
+	}
+
+	fmt.Printf("Wrote the digests of the pushed images to %s\n", path)
+	return nil
+}
+
+// applyPushDigests replaces the image of each function built from a handler
+// with the image and digest from a push manifest, so deploy runs exactly the
+// image which was pushed even if its tag has moved since
+func applyPushDigests(services *stack.Services, path string) error {
+	manifest, err := readPushManifest(path)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range services.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		function := services.Functions[name]
+		if function.SkipBuild {
+			continue
+		}
+
+		reference, ok := manifest[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("- %s: no digest, run faas-cli push first", name))
+			continue
+		}
+
+		if pushed := strings.SplitN(reference, "@", 2)[0]; pushed != function.Image {
+			problems = append(problems, fmt.Sprintf("- %s: the digest is for %s not %s, run faas-cli push again", name, pushed, function.Image))
+			continue
+		}
+
+		function.Image = reference
+		services.Functions[name] = function
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unable to deploy the pushed digests from %s:\n%s", path, strings.Join(problems, "\n"))
+	}
+	return nil
+}
@@ -4,9 +4,16 @@
 package commands
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker-credential-helpers/client"
 	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
 )
 
 func Test_PushValidation(t *testing.T) {
@@ -40,3 +47,224 @@ func Test_PushValidation(t *testing.T) {
 
 	}
 }
+
+// chdirTemp changes into a new temporary folder for the files written by
+// push, call the returned func to change back and remove it
+func chdirTemp(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "faas-cli-push")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	return func() {
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_pushStack_MultiArch(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+	defer chdirTemp(t)()
+
+	platforms = []string{"linux/amd64", "linux/arm/v7"}
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"figlet": {Image: "alexellis/figlet:0.1"},
+		},
+	}
+
+	backend := &fakeBackend{}
+	test.CaptureStdout(func() {
+		if err := pushStack(services, backend, 1, false); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	wantPushes := "alexellis/figlet:0.1-linux-amd64,alexellis/figlet:0.1-linux-arm-v7"
+	if got := strings.Join(backend.pushes, ","); got != wantPushes {
+		t.Fatalf("want pushes %s, got %s", wantPushes, got)
+	}
+
+	images := backend.manifestLists["alexellis/figlet:0.1"]
+	if len(images) != 2 || images[1].Platform != "linux/arm/v7" || images[1].Image != "alexellis/figlet:0.1-linux-arm-v7" {
+		t.Fatalf("want a manifest list of both platforms, got %+v", images)
+	}
+}
+
+func Test_pushStack_RetriesAndSavesDigests(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+	defer chdirTemp(t)()
+
+	defer func(delay time.Duration) { pushRetryDelay = delay }(pushRetryDelay)
+	pushRetryDelay = 0
+	pushRetries = 3
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"figlet":   {Image: "alexellis/figlet:0.1"},
+			"nodeinfo": {Image: "alexellis/nodeinfo:0.1"},
+		},
+	}
+
+	backend := &fakeBackend{
+		flaky: map[string]int{"alexellis/figlet:0.1": 2},
+		fail:  map[string]bool{"alexellis/nodeinfo:0.1": true},
+	}
+
+	var pushErr error
+	test.CaptureStdout(func() {
+		pushErr = pushStack(services, backend, 1, false)
+	})
+
+	want := "1 of 2 function(s) failed to push:\n- nodeinfo: push of alexellis/nodeinfo:0.1 failed: denied: requested access to the resource is denied"
+	if pushErr == nil || pushErr.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, pushErr)
+	}
+
+	// The timeouts are retried and the denied push is not
+	wantPushes := "alexellis/figlet:0.1,alexellis/figlet:0.1,alexellis/figlet:0.1,alexellis/nodeinfo:0.1"
+	if got := strings.Join(backend.pushes, ","); got != wantPushes {
+		t.Fatalf("want pushes %s, got %s", wantPushes, got)
+	}
+
+	manifest, err := readPushManifest(pushManifestFile)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if len(manifest) != 1 || manifest["figlet"] != "alexellis/figlet:0.1@"+fakeDigest {
+		t.Fatalf("want only the digest of figlet saved, got %v", manifest)
+	}
+}
+
+func Test_applyPushDigests(t *testing.T) {
+	defer chdirTemp(t)()
+
+	manifest := `{
+  "figlet": "alexellis/figlet:0.1@` + fakeDigest + `",
+  "qrcode": "alexellis/qrcode:0.1@` + fakeDigest + `"
+}`
+	if err := ioutil.WriteFile(pushManifestFile, []byte(manifest), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"figlet":  {Image: "alexellis/figlet:0.1"},
+			"gateway": {Image: "openfaas/gateway:0.7.0", SkipBuild: true},
+		},
+	}
+	if err := applyPushDigests(services, pushManifestFile); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if got := services.Functions["figlet"].Image; got != "alexellis/figlet:0.1@"+fakeDigest {
+		t.Errorf("want figlet deployed by digest, got %s", got)
+	}
+	if got := services.Functions["gateway"].Image; got != "openfaas/gateway:0.7.0" {
+		t.Errorf("want skip_build images left as they are, got %s", got)
+	}
+
+	services = &stack.Services{
+		Functions: map[string]stack.Function{
+			"nodeinfo": {Image: "alexellis/nodeinfo:0.1"},
+			"qrcode":   {Image: "alexellis/qrcode:0.2"},
+		},
+	}
+	err := applyPushDigests(services, pushManifestFile)
+	want := "unable to deploy the pushed digests from push-manifest.json:\n" +
+		"- nodeinfo: no digest, run faas-cli push first\n" +
+		"- qrcode: the digest is for alexellis/qrcode:0.1 not alexellis/qrcode:0.2, run faas-cli push again"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, err)
+	}
+}
+
+func Test_checkPushImages(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-docker-config")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { configDir = dir }(configDir)
+	configDir = dir
+
+	config := `{"auths": {"registry.example.com:5000": {"auth": "YWxleGVsbGlzOnNlY3JldA=="}}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	functions := map[string]stack.Function{
+		"app":      {Image: "registry.example.com:5000/team/app:1.0"},
+		"figlet":   {Image: "alexellis/figlet:0.1"},
+		"gateway":  {Image: "openfaas/gateway:0.7.0", SkipBuild: true},
+		"local":    {Image: "localhost:5000/local"},
+		"nodeinfo": {Image: "nodeinfo"},
+		"qrcode":   {Image: "AlexEllis/qrcode"},
+	}
+
+	want := []string{
+		`- nodeinfo: image "nodeinfo" has no registry or username prefix such as user1/nodeinfo`,
+		`- qrcode: invalid image "AlexEllis/qrcode": the repository must be lowercase`,
+		`- figlet: not logged in to the Docker Hub, run "docker login" or pass --skip-auth-check`,
+	}
+	got := checkPushImages(functions)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("want problems:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	skipAuthCheck = true
+	if got := checkPushImages(functions); len(got) != 2 {
+		t.Fatalf("want only the invalid images with --skip-auth-check, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func Test_checkPushImages_CredsStore(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-docker-config")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { configDir = dir }(configDir)
+	configDir = dir
+
+	defer func(helper func(string) client.ProgramFunc) { credentialHelper = helper }(credentialHelper)
+	credentialHelper = func(name string) client.ProgramFunc {
+		return func(args ...string) client.Program {
+			return &fakeCredentialHelper{
+				secrets:  map[string][2]string{"registry.example.com:5000": {"alexellis", "secret"}},
+				failures: map[string]string{"broken.example.com": "error getting credentials - err: exit status 1, out: `keychain locked`"},
+			}
+		}
+	}
+
+	config := `{"credsStore": "desktop", "auths": {"registry.example.com:5000": {}, "broken.example.com": {}, "unused.example.com": {}}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	functions := map[string]stack.Function{
+		"app": {Image: "registry.example.com:5000/team/app:1.0"},
+	}
+	if got := checkPushImages(functions); len(got) > 0 {
+		t.Fatalf("want no problems when the registries pushed to have credentials, got:\n%s", strings.Join(got, "\n"))
+	}
+
+	functions["broken"] = stack.Function{Image: "broken.example.com/team/broken:1.0"}
+	got := checkPushImages(functions)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "- broken: unable to get the credentials for broken.example.com:") {
+		t.Fatalf("want the credsStore failure reported against broken, got:\n%s", strings.Join(got, "\n"))
+	}
+}
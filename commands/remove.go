@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -42,7 +43,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+		parsedServices, err := parseStack(stackFiles(), regex, filter)
 		if err != nil {
 			return err
 		}
@@ -55,6 +56,15 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 	gatewayAddress = getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	timeout := defaultGatewayTimeout
+	client, err := newProxyClient(gatewayAddress, &timeout)
+	if err != nil {
+		return err
+	}
+
 	if len(services.Functions) > 0 {
 		if len(services.Provider.Network) == 0 {
 			services.Provider.Network = defaultNetwork
@@ -62,9 +72,12 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 		for k, function := range services.Functions {
 			function.Name = k
-			fmt.Printf("Deleting: %s.\n", function.Name)
+			if ctx.Err() != nil {
+				return fmt.Errorf("remove interrupted before deleting %s: %s", function.Name, ctx.Err())
+			}
 
-			proxy.DeleteFunction(gatewayAddress, function.Name)
+			fmt.Printf("Deleting: %s.\n", function.Name)
+			deleteFunction(ctx, client, function.Name)
 		}
 	} else {
 		if len(args) < 1 {
@@ -73,8 +86,24 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 		functionName = args[0]
 		fmt.Printf("Deleting: %s.\n", functionName)
-		proxy.DeleteFunction(gatewayAddress, functionName)
+		deleteFunction(ctx, client, functionName)
 	}
 
 	return nil
 }
+
+// deleteFunction removes a function through the client and prints the outcome,
+// a function which does not exist is not treated as an error
+func deleteFunction(ctx context.Context, client *proxy.Client, functionName string) error {
+	err := client.Delete(ctx, functionName)
+	switch {
+	case err == nil:
+		fmt.Println("Removing old function.")
+	case proxy.IsNotFound(err):
+		fmt.Println("No existing function to remove")
+	default:
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// Outcomes of building or pushing a function
+const (
+	resultBuilt         = "built"
+	resultPushed        = "pushed"
+	resultShrinkwrapped = "shrink-wrapped"
+	resultUnchanged     = "unchanged"
+	resultSkipped       = "skipped"
+	resultFailed        = "failed"
+	resultNotStarted    = "not started"
+)
+
+// functionResult is the outcome of building or pushing one function, a
+// multi-arch function has a result for each of its platforms
+type functionResult struct {
+	Name     string
+	Platform string
+	Status   string
+	Duration time.Duration
+	ImageID  string
+	Digest   string
+	Err      error
+
+	Platforms []functionResult
+}
+
+// runFunctions runs work for each function on queueDepth workers and
+// returns a result per function sorted by name. With failFast no more
+// functions are queued after the first failure, those left are "not started".
+func runFunctions(functions map[string]stack.Function, queueDepth int, failFast bool, work func(index int, function stack.Function) functionResult) []functionResult {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := map[string]functionResult{}
+	var resultsLock sync.Mutex
+
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	workChannel := make(chan stack.Function)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < queueDepth; i++ {
+		wg.Add(1)
+		go func(index int) {
+			for function := range workChannel {
+				// Functions received after a failure are left as not started
+				select {
+				case <-stop:
+					continue
+				default:
+				}
+
+				start := time.Now()
+				result := work(index, function)
+				result.Name = function.Name
+				result.Duration = time.Since(start)
+
+				resultsLock.Lock()
+				results[function.Name] = result
+				resultsLock.Unlock()
+
+				if result.Status == resultFailed && failFast {
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+
+			fmt.Printf(aec.YellowF.Apply("[%d] worker done.\n"), index)
+			wg.Done()
+		}(i)
+	}
+
+queue:
+	for _, name := range names {
+		function := functions[name]
+		function.Name = name
+
+		select {
+		case <-stop:
+			break queue
+		default:
+		}
+
+		select {
+		case workChannel <- function:
+		case <-stop:
+			break queue
+		}
+	}
+
+	close(workChannel)
+
+	wg.Wait()
+
+	var sorted []functionResult
+	for _, name := range names {
+		result, ok := results[name]
+		if !ok {
+			result = functionResult{Name: name, Status: resultNotStarted}
+		}
+		sorted = append(sorted, result)
+	}
+	return sorted
+}
+
+// printResults writes a summary table of the results, with a row for each
+// platform of a multi-arch function and the digests of pushed images
+func printResults(w io.Writer, results []functionResult) {
+	multiArch := false
+	pushed := false
+	for _, result := range results {
+		if len(result.Platforms) > 0 {
+			multiArch = true
+		}
+		if len(result.Digest) > 0 {
+			pushed = true
+		}
+		for _, platformResult := range result.Platforms {
+			if len(platformResult.Digest) > 0 {
+				pushed = true
+			}
+		}
+	}
+
+	header := []string{"FUNCTION", "STATUS", "DURATION", "IMAGE ID"}
+	if multiArch {
+		header = []string{"FUNCTION", "PLATFORM", "STATUS", "DURATION", "IMAGE ID"}
+	}
+	if pushed {
+		header = append(header, "DIGEST")
+	}
+	rows := [][]string{header}
+
+	for _, result := range results {
+		rowResults := result.Platforms
+		if len(rowResults) == 0 {
+			rowResults = []functionResult{result}
+		}
+
+		for _, rowResult := range rowResults {
+			row := []string{result.Name}
+			if multiArch {
+				platform := rowResult.Platform
+				if len(platform) == 0 {
+					platform = "-"
+				}
+				row = append(row, platform)
+			}
+			row = append(row, rowResult.Status, formatDuration(rowResult.Duration), shortImageID(rowResult.ImageID))
+			if pushed {
+				row = append(row, shortImageID(rowResult.Digest))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	fmt.Fprintln(w)
+	writeTable(w, rows)
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration <= 0 {
+		return "-"
+	}
+	return duration.Round(100 * time.Millisecond).String()
+}
+
+func shortImageID(imageID string) string {
+	imageID = strings.TrimPrefix(imageID, "sha256:")
+	if len(imageID) > 12 {
+		imageID = imageID[:12]
+	}
+	if len(imageID) == 0 {
+		return "-"
+	}
+	return imageID
+}
+
+// resultsError returns an error listing the failed functions, or nil when
+// none failed. Functions which were not started count as failures.
+func resultsError(results []functionResult, action string) error {
+	var messages []string
+	failed := 0
+	for _, result := range results {
+		switch result.Status {
+		case resultFailed:
+			failed++
+			if len(result.Platforms) == 0 {
+				messages = append(messages, fmt.Sprintf("- %s: %s", result.Name, result.Err))
+			}
+			for _, platformResult := range result.Platforms {
+				if platformResult.Status == resultFailed {
+					messages = append(messages, fmt.Sprintf("- %s (%s): %s", result.Name, platformResult.Platform, platformResult.Err))
+				}
+			}
+		case resultNotStarted:
+			failed++
+			messages = append(messages, fmt.Sprintf("- %s: not started after an earlier failure", result.Name))
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d function(s) failed to %s:\n%s", failed, len(results), action, strings.Join(messages, "\n"))
+}
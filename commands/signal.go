@@ -0,0 +1,47 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// commandContext returns the context for the gateway and store calls made by a
+// command. It carries the deadline set by the global --timeout flag and is
+// cancelled when SIGINT or SIGTERM is received, after which a second signal
+// stops the CLI straight away. The returned cancel function must be called to
+// stop listening for signals.
+func commandContext() (context.Context, context.CancelFunc) {
+	parent, cancelParent := context.WithCancel(context.Background())
+
+	ctx, cancelTimeout := parent, context.CancelFunc(func() {})
+	if commandTimeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(parent, commandTimeout)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			// restore the default handling so a second signal is not swallowed
+			// while requests which ignore the context are still running
+			signal.Stop(signals)
+			fmt.Fprintf(os.Stderr, "\nReceived %s, cancelling in-flight requests. Send it again to exit now.\n", sig)
+			cancelParent()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancelTimeout()
+		cancelParent()
+	}
+}
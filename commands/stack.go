@@ -0,0 +1,81 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	stackCmd.AddCommand(stackRenderCmd)
+	stackCmd.AddCommand(stackSchemaCmd)
+	faasCmd.AddCommand(stackCmd)
+}
+
+var stackCmd = &cobra.Command{
+	Use:   `stack`,
+	Short: "OpenFaaS stack file commands",
+	Long:  "Work with the stack file describing your functions",
+}
+
+var stackRenderCmd = &cobra.Command{
+	Use:   `render [-f YAML_FILE]... [--regex "REGEX"] [--filter "WILDCARD"]`,
+	Short: "Print the stack file after merging overlays",
+	Long: `Prints the stack file which other commands would use, after ${VAR}
+references are substituted and each -f overlay is merged on top of the files
+before it.
+
+Values written in an overlay replace the earlier ones, environment and labels
+are merged key by key, secrets and environment_file are appended to and other
+lists such as constraints are replaced. Set a field to null to remove it.`,
+	Example: `  faas-cli stack render -f base.yml -f prod.yml
+  faas-cli stack render -f base.yml -f prod.yml --filter "*gif*"
+  faas-cli stack render -f stack.yml --env-subst-file prod.env`,
+	RunE: runStackRender,
+}
+
+var stackSchemaCmd = &cobra.Command{
+	Use:   `schema`,
+	Short: "Print the JSON Schema of the stack file format",
+	Long: `Prints a JSON Schema for stack files which editors can use for completion
+and validation. With the YAML language server add this comment to the top of
+stack.yml:
+
+  # yaml-language-server: $schema=./stack.schema.json`,
+	Example: `  faas-cli stack schema > stack.schema.json`,
+	RunE:    runStackSchema,
+}
+
+func runStackSchema(cmd *cobra.Command, args []string) error {
+	schema, err := stack.JSONSchema()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(schema))
+	return nil
+}
+
+func runStackRender(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with -f")
+	}
+
+	services, err := parseStack(stackFiles(), regex, filter)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(services)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(out))
+	return nil
+}
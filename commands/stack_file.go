@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// yamlFileValue is the value of the repeatable --yaml flag. The first file
+// replaces the default stack.yml and becomes yamlFile, later files are
+// overlays.
+type yamlFileValue struct{}
+
+func (v *yamlFileValue) Set(value string) error {
+	yamlFiles = append(yamlFiles, value)
+	yamlFile = yamlFiles[0]
+	return nil
+}
+
+func (v *yamlFileValue) String() string {
+	return strings.Join(yamlFiles, ",")
+}
+
+func (v *yamlFileValue) Type() string {
+	return "stringArray"
+}
+
+// stackFiles returns the stack file followed by its overlays in the order
+// they are merged
+func stackFiles() []string {
+	files := []string{yamlFile}
+	if len(yamlFiles) > 1 {
+		files = append(files, yamlFiles[1:]...)
+	}
+	return files
+}
+
+// parseStack parses and merges the stack files
+func parseStack(files []string, regex string, filter string) (*stack.Services, error) {
+	lookup, err := stackLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	return stack.ParseYAMLFilesWithLookup(files, regex, filter, lookup)
+}
+
+// stackLookup returns the values for ${VAR} references in stack files, from
+// the process environment and then from the --env-subst-file inputs
+func stackLookup() (stack.LookupFunc, error) {
+	values := make(map[string]string)
+
+	// Later files override the values of earlier ones
+	for _, file := range envSubstFiles {
+		fileValues, err := stack.ReadVariablesFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range fileValues {
+			values[k] = v
+		}
+	}
+
+	return stack.NewLookup(values), nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_parseStack_EnvSubstFiles(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-stack")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"stack.yml": `provider:
+  name: faas
+  gateway: ${GATEWAY:-http://127.0.0.1:8080}
+
+functions:
+  figlet:
+    image: ${REGISTRY}/figlet:${TAG}
+`,
+		"base.env": "REGISTRY=docker.io\nTAG=latest\n",
+		"prod.env": "TAG=0.2.0\n",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	envSubstFiles = []string{filepath.Join(dir, "base.env"), filepath.Join(dir, "prod.env")}
+
+	services, err := parseStack([]string{filepath.Join(dir, "stack.yml")}, "", "")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if image := services.Functions["figlet"].Image; image != "docker.io/figlet:0.2.0" {
+		t.Fatalf("want image docker.io/figlet:0.2.0, got %s", image)
+	}
+	if gateway := services.Provider.GatewayURL; gateway != "http://127.0.0.1:8080" {
+		t.Fatalf("want the default gateway, got %s", gateway)
+	}
+}
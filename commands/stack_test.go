@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_stackRender(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-stack")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"base.yml": `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: functions/figlet:latest
+    environment:
+      write_debug: true
+    secrets:
+      - api-key
+`,
+		"prod.yml": `provider:
+  gateway: https://gateway.example.com
+
+functions:
+  figlet:
+    image: functions/figlet:0.2.0
+    environment:
+      read_timeout: 10s
+    secrets:
+      - registry-key
+`,
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	// A remote file must not add anything but YAML to the output
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"stack",
+			"render",
+			"-f", filepath.Join(dir, "base.yml"),
+			"-f", server.URL + "/prod.yml",
+		})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	want := `provider:
+  name: faas
+  gateway: https://gateway.example.com
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: functions/figlet:0.2.0
+    environment:
+      read_timeout: 10s
+      write_debug: "true"
+    secrets:
+    - api-key
+    - registry-key
+`
+	if stdOut != want {
+		t.Fatalf("want merged stack:\n%s\ngot:\n%s", want, stdOut)
+	}
+}
+
+func Test_stackSchema(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"stack", "schema"})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	})
+
+	golden, err := ioutil.ReadFile("../stack/testdata/stack.schema.json")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if stdOut != string(golden) {
+		t.Fatalf("want the published schema, got:\n%s", stdOut)
+	}
+}
@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -39,7 +40,7 @@ var storeCmd = &cobra.Command{
 	Long:  "Allows browsing and deploying OpenFaaS functions from a store",
 }
 
-func storeList(store string) ([]schema.StoreItem, error) {
+func storeList(ctx context.Context, store string) ([]schema.StoreItem, error) {
 	var results []schema.StoreItem
 
 	store = strings.TrimRight(store, "/")
@@ -47,11 +48,19 @@ func storeList(store string) ([]schema.StoreItem, error) {
 	timeout := 60 * time.Second
 	client := proxy.MakeHTTPClient(&timeout)
 
-	res, err := client.Get(store)
+	req, err := http.NewRequest(http.MethodGet, store, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to OpenFaaS store at URL: %s", store)
 	}
 
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("cannot connect to OpenFaaS store at URL: %s", store)
+	}
+
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
@@ -58,7 +58,10 @@ func runStoreDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("please provide the function name")
 	}
 
-	storeItems, err := storeList(storeAddress)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	storeItems, err := storeList(ctx, storeAddress)
 	if err != nil {
 		return err
 	}
@@ -109,5 +112,5 @@ func runStoreDeploy(cmd *cobra.Command, args []string) error {
 
 	gateway = getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
 
-	return deployImage(item.Image, item.Fprocess, itemName, registryAuth, storeDeployFlags)
+	return deployImage(ctx, item.Image, item.Fprocess, itemName, registryAuth, storeDeployFlags)
 }
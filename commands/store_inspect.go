@@ -6,24 +6,29 @@ package commands
 import (
 	"bytes"
 	"fmt"
-	"text/tabwriter"
+	"io"
+	"os"
 
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/spf13/cobra"
 )
 
+var storeInspectOutput string
+
 func init() {
 	// Setup flags used by store command
-	storeInspectCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output for the field values")
+	storeInspectCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output for the field values, same as --output wide")
+	addOutputFlag(storeInspectCmd, &storeInspectOutput)
 
 	storeCmd.AddCommand(storeInspectCmd)
 }
 
 var storeInspectCmd = &cobra.Command{
-	Use:   `inspect (FUNCTION_NAME|FUNCTION_TITLE) [--url STORE_URL]`,
+	Use:   `inspect (FUNCTION_NAME|FUNCTION_TITLE) [--url STORE_URL] [--output table|wide|json|yaml|go-template=TEMPLATE]`,
 	Short: "Show details of OpenFaaS function from a store",
 	Example: `  faas-cli store inspect NodeInfo
-  faas-cli store inspect NodeInfo --url https://domain:port/store.json`,
+  faas-cli store inspect NodeInfo --url https://domain:port/store.json
+  faas-cli store inspect NodeInfo --output yaml`,
 	RunE: runStoreInspect,
 }
 
@@ -32,7 +37,19 @@ func runStoreInspect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("please provide the function name")
 	}
 
-	storeItems, err := storeList(storeAddress)
+	if verbose && (storeInspectOutput == "" || storeInspectOutput == tableOutput) {
+		storeInspectOutput = wideOutput
+	}
+
+	format, err := parseOutputFormat(storeInspectOutput)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	storeItems, err := storeList(ctx, storeAddress)
 	if err != nil {
 		return err
 	}
@@ -42,26 +59,26 @@ func runStoreInspect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("function '%s' not found", functionName)
 	}
 
-	content := storeRenderItem(item)
-	fmt.Print(content)
-
-	return nil
+	return format.print(os.Stdout, item, func(w io.Writer, wide bool) {
+		fmt.Fprint(w, storeRenderItem(item, wide))
+	})
 }
 
-func storeRenderItem(item *schema.StoreItem) string {
+func storeRenderItem(item *schema.StoreItem, wide bool) string {
+	rows := [][]string{
+		{"FUNCTION", "DESCRIPTION", "IMAGE", "PROCESS", "REPO"},
+		{
+			item.Title,
+			storeRenderDescription(item.Description, wide),
+			item.Image,
+			item.Fprocess,
+			item.RepoURL,
+		},
+	}
+
 	var b bytes.Buffer
-	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
-	fmt.Fprintln(w)
-	fmt.Fprintln(w, "FUNCTION\tDESCRIPTION\tIMAGE\tPROCESS\tREPO")
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-		item.Title,
-		storeRenderDescription(item.Description),
-		item.Image,
-		item.Fprocess,
-		item.RepoURL,
-	)
-
-	fmt.Fprintln(w)
-	w.Flush()
+	fmt.Fprintln(&b)
+	writeTable(&b, rows)
+	fmt.Fprintln(&b)
 	return b.String()
 }
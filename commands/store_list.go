@@ -6,59 +6,86 @@ package commands
 import (
 	"bytes"
 	"fmt"
-	"text/tabwriter"
+	"io"
+	"os"
 
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/spf13/cobra"
 )
 
+var storeListOutput string
+
 func init() {
 	// Setup flags used by store command
-	storeListCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output for the field values")
+	storeListCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output for the field values, same as --output wide")
+	addOutputFlag(storeListCmd, &storeListOutput)
 
 	storeCmd.AddCommand(storeListCmd)
 }
 
 var storeListCmd = &cobra.Command{
-	Use:     `list [--url STORE_URL]`,
-	Short:   "List available OpenFaaS functions in a store",
-	Example: `  faas-cli store list --url https://domain:port/store.json`,
-	RunE:    runStoreList,
+	Use:   `list [--url STORE_URL] [--output table|wide|json|yaml|go-template=TEMPLATE]`,
+	Short: "List available OpenFaaS functions in a store",
+	Example: `  faas-cli store list --url https://domain:port/store.json
+  faas-cli store list --output json`,
+	RunE: runStoreList,
 }
 
 func runStoreList(cmd *cobra.Command, args []string) error {
-	items, err := storeList(storeAddress)
+	if verbose && (storeListOutput == "" || storeListOutput == tableOutput) {
+		storeListOutput = wideOutput
+	}
+
+	format, err := parseOutputFormat(storeListOutput)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	items, err := storeList(ctx, storeAddress)
 	if err != nil {
 		return err
 	}
 
-	if len(items) == 0 {
+	if len(items) == 0 && format.isText() {
 		fmt.Printf("The store is empty.")
 		return nil
 	}
 
-	fmt.Print(storeRenderItems(items))
+	if items == nil {
+		items = []schema.StoreItem{}
+	}
 
-	return nil
+	return format.print(os.Stdout, items, func(w io.Writer, wide bool) {
+		fmt.Fprint(w, storeRenderItems(items, wide))
+	})
 }
 
-func storeRenderItems(items []schema.StoreItem) string {
-	var b bytes.Buffer
-	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
-	fmt.Fprintln(w)
-	fmt.Fprintln(w, "FUNCTION\tDESCRIPTION")
+func storeRenderItems(items []schema.StoreItem, wide bool) string {
+	rows := [][]string{{"FUNCTION", "DESCRIPTION"}}
+	if wide {
+		rows[0] = append(rows[0], "IMAGE")
+	}
 
 	for _, item := range items {
-		fmt.Fprintf(w, "%s\t%s\n", item.Title, storeRenderDescription(item.Description))
+		row := []string{item.Title, storeRenderDescription(item.Description, wide)}
+		if wide {
+			row = append(row, item.Image)
+		}
+		rows = append(rows, row)
 	}
 
-	fmt.Fprintln(w)
-	w.Flush()
+	var b bytes.Buffer
+	fmt.Fprintln(&b)
+	writeTable(&b, rows)
+	fmt.Fprintln(&b)
 	return b.String()
 }
 
-func storeRenderDescription(descr string) string {
-	if !verbose && len(descr) > maxDescriptionLen {
+func storeRenderDescription(descr string, wide bool) string {
+	if !wide && len(descr) > maxDescriptionLen {
 		return descr[0:maxDescriptionLen-3] + "..."
 	}
 
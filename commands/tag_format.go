@@ -0,0 +1,149 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/versioncontrol"
+	"github.com/spf13/cobra"
+)
+
+// Strategies for --tag, a value containing "{{" is a template instead
+const (
+	tagLatest   = "latest"
+	tagSHA      = "sha"
+	tagBranch   = "branch"
+	tagDescribe = "describe"
+)
+
+var tagFormat string
+
+func addTagFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&tagFormat, "tag", tagLatest, `Image tag strategy: "latest" uses the image as written, "sha", "branch" and "describe" add git details of the handler to its tag, or a template such as "{{.Image}}:{{.GitSHA}}"`)
+}
+
+// imageTag holds the values of a --tag template for a function, the git
+// details are read from the repository of the handler folder when used
+type imageTag struct {
+	// Image is the image without its tag, such as alexellis/figlet
+	Image string
+
+	// Tag is the tag of the image, latest when it has none
+	Tag string
+
+	// Name is the name of the function
+	Name string
+
+	handler string
+}
+
+// GitSHA is the short commit which last changed the handler folder, so a
+// commit to another function in the same repository keeps its tag
+func (t imageTag) GitSHA() (string, error) {
+	sha, err := versioncontrol.GitLogSHA.Output(t.handler, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(sha) == 0 {
+		return "", fmt.Errorf("%s has no commits, commit the handler to tag its image by commit", t.handler)
+	}
+	return sha, nil
+}
+
+// GitBranch is the branch checked out
+func (t imageTag) GitBranch() (string, error) {
+	return versioncontrol.GitBranch.Output(t.handler, nil)
+}
+
+// GitDescribe is the closest tag with the commits since it
+func (t imageTag) GitDescribe() (string, error) {
+	return versioncontrol.GitDescribe.Output(t.handler, nil)
+}
+
+// applyTagFormat rewrites the image of each function which is built from a
+// handler, so build, push and deploy all resolve the same image for a format
+func applyTagFormat(services *stack.Services, format string) error {
+	if len(format) == 0 || format == tagLatest {
+		return nil
+	}
+
+	for name, function := range services.Functions {
+		if function.SkipBuild || len(function.Handler) == 0 {
+			continue
+		}
+
+		function.Name = name
+		image, err := formatImage(function, format)
+		if err != nil {
+			return fmt.Errorf("unable to tag the image of %s: %s", name, err)
+		}
+
+		function.Image = image
+		services.Functions[name] = function
+	}
+
+	return nil
+}
+
+// formatImage returns the image of a function tagged by a --tag format
+func formatImage(function stack.Function, format string) (string, error) {
+	values := splitImageTag(function.Image)
+	values.Name = function.Name
+	values.handler = function.Handler
+
+	var suffix string
+	var err error
+
+	switch format {
+	case tagSHA:
+		suffix, err = values.GitSHA()
+	case tagBranch:
+		var branch, sha string
+		if branch, err = values.GitBranch(); err == nil {
+			sha, err = values.GitSHA()
+		}
+		suffix = branch + "-" + sha
+	case tagDescribe:
+		suffix, err = values.GitDescribe()
+	default:
+		if !strings.Contains(format, "{{") {
+			return "", fmt.Errorf(`unknown tag strategy %q, use latest, sha, branch, describe or a template such as "{{.Image}}:{{.GitSHA}}"`, format)
+		}
+		return executeTagTemplate(format, values)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Branch names such as feature/login are not valid in a tag
+	suffix = strings.Replace(suffix, "/", "-", -1)
+	return values.Image + ":" + values.Tag + "-" + suffix, nil
+}
+
+func executeTagTemplate(format string, values imageTag) (string, error) {
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag template %q: %s", format, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", fmt.Errorf("invalid tag template %q: %s", format, err)
+	}
+	return out.String(), nil
+}
+
+// splitImageTag splits an image such as registry:5000/figlet:0.1 into its
+// name and tag, the tag is latest when there is none
+func splitImageTag(image string) imageTag {
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		return imageTag{Image: image[:index], Tag: image[index+1:]}
+	}
+	return imageTag{Image: image, Tag: tagLatest}
+}
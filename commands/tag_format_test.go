@@ -0,0 +1,149 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/versioncontrol"
+)
+
+func Test_applyTagFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tag")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	handler := filepath.Join(dir, "figlet")
+	if err := os.MkdirAll(handler, 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := versioncontrol.GitInitRepo.Invoke(dir, map[string]string{"dir": "."}); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	sha, err := versioncontrol.GitShortSHA.Output(handler, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	// A commit outside the handler folder moves HEAD but not the handler SHA
+	if err := os.MkdirAll(filepath.Join(dir, "nodeinfo"), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "nodeinfo", "Dockerfile"), []byte("FROM alpine:3.8\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := versioncontrol.GitInitRepo.Invoke(dir, map[string]string{"dir": "."}); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	head, err := versioncontrol.GitShortSHA.Output(handler, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if head == sha {
+		t.Fatalf("want a second commit, got HEAD %s", head)
+	}
+	branch, err := versioncontrol.GitBranch.Output(handler, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if !regexp.MustCompile("^[0-9a-f]{7,}$").MatchString(sha) {
+		t.Fatalf("want a short commit, got %q", sha)
+	}
+
+	testCases := []struct {
+		format string
+		image  string
+		want   string
+	}{
+		{format: "", image: "alexellis/figlet:0.1", want: "alexellis/figlet:0.1"},
+		{format: "latest", image: "alexellis/figlet:0.1", want: "alexellis/figlet:0.1"},
+		{format: "sha", image: "alexellis/figlet:0.1", want: "alexellis/figlet:0.1-" + sha},
+		{format: "sha", image: "registry.example.com:5000/figlet", want: "registry.example.com:5000/figlet:latest-" + sha},
+		{format: "branch", image: "alexellis/figlet", want: "alexellis/figlet:latest-" + branch + "-" + sha},
+		{format: "describe", image: "alexellis/figlet:0.1", want: "alexellis/figlet:0.1-" + head},
+		{format: "{{.Image}}:{{.GitSHA}}", image: "alexellis/figlet:0.1", want: "alexellis/figlet:" + sha},
+		{format: "{{.Image}}:{{.Name}}-{{.Tag}}", image: "alexellis/figlet:0.1", want: "alexellis/figlet:figlet-0.1"},
+	}
+
+	for _, testCase := range testCases {
+		services := &stack.Services{
+			Functions: map[string]stack.Function{
+				"figlet":   {Handler: handler, Image: testCase.image},
+				"nodeinfo": {SkipBuild: true, Image: "functions/nodeinfo:latest"},
+			},
+		}
+
+		if err := applyTagFormat(services, testCase.format); err != nil {
+			t.Fatalf("--tag %q: Error returned: %s", testCase.format, err)
+		}
+		if got := services.Functions["figlet"].Image; got != testCase.want {
+			t.Errorf("--tag %q of %s: want %s, got %s", testCase.format, testCase.image, testCase.want, got)
+		}
+		if got := services.Functions["nodeinfo"].Image; got != "functions/nodeinfo:latest" {
+			t.Errorf("--tag %q: want skip_build images unchanged, got %s", testCase.format, got)
+		}
+	}
+}
+
+func Test_applyTagFormat_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tag")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []string{"sha1", "{{.Image}}:{{.Version}}", "sha"} {
+		services := &stack.Services{
+			Functions: map[string]stack.Function{
+				"figlet": {Handler: dir, Image: "alexellis/figlet"},
+			},
+		}
+
+		// dir is not a git repository, so sha also fails
+		if err := applyTagFormat(services, format); err == nil {
+			t.Errorf("--tag %q: want an error", format)
+		}
+	}
+}
+
+func Test_applyTagFormat_UncommittedHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tag")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "stack.yml"), []byte("functions:\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := versioncontrol.GitInitRepo.Invoke(dir, map[string]string{"dir": "."}); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	handler := filepath.Join(dir, "figlet")
+	if err := os.MkdirAll(handler, 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"figlet": {Handler: handler, Image: "alexellis/figlet"},
+		},
+	}
+
+	if err := applyTagFormat(services, tagSHA); err == nil || !strings.Contains(err.Error(), "has no commits") {
+		t.Errorf("want an error for a handler with no commits, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// templateLockFile records the commit each language in ./template was pulled
+// from, so builds on other machines use the same templates
+const templateLockFile = "./template.lock"
+
+type templateLock struct {
+	Templates map[string]lockedTemplate `yaml:"templates"`
+	Sources   []lockedSourceLanguages   `yaml:"sources,omitempty"`
+}
+
+// lockedTemplate is where a language template was pulled from and a hash of
+// its folder to tell when ./template has been changed since
+type lockedTemplate struct {
+	Source string `yaml:"source"`
+	Ref    string `yaml:"ref,omitempty"`
+	Commit string `yaml:"commit"`
+	Hash   string `yaml:"hash"`
+}
+
+// lockedSourceLanguages is every language a repository had at a ref when it
+// was last pulled, whether or not it was written to ./template
+type lockedSourceLanguages struct {
+	Source    string   `yaml:"source"`
+	Ref       string   `yaml:"ref,omitempty"`
+	Languages []string `yaml:"languages"`
+}
+
+// splitTemplateSource splits a source such as
+// https://github.com/openfaas/templates.git#1.2.0 into the repository and the
+// tag, branch or commit after the "#"
+func splitTemplateSource(source string) (string, string) {
+	if index := strings.LastIndex(source, "#"); index > -1 {
+		return source[:index], source[index+1:]
+	}
+	return source, ""
+}
+
+// readTemplateLock reads a lock file, a missing file locks no templates
+func readTemplateLock(path string) (*templateLock, error) {
+	lock := &templateLock{Templates: map[string]lockedTemplate{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", path, err)
+	}
+	if lock.Templates == nil {
+		lock.Templates = map[string]lockedTemplate{}
+	}
+	return lock, nil
+}
+
+func (lock *templateLock) write(path string) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	header := "# Written by faas-cli template pull, commit this file with your stack.yml\n"
+	return ioutil.WriteFile(path, append([]byte(header), data...), 0644)
+}
+
+// lockTemplates records the commit of languages which were just pulled into
+// ./template, the other languages keep their entries. provided is every
+// language of the repository at ref.
+func lockTemplates(path string, repository string, ref string, commit string, languages []string, provided []string) error {
+	lock, err := readTemplateLock(path)
+	if err != nil {
+		return err
+	}
+
+	for _, language := range languages {
+		hash, err := builder.FolderHash(filepath.Join(templateDirectory, language))
+		if err != nil {
+			return err
+		}
+		lock.Templates[language] = lockedTemplate{Source: repository, Ref: ref, Commit: commit, Hash: hash}
+	}
+
+	provided = append([]string{}, provided...)
+	sort.Strings(provided)
+	entry := lockedSourceLanguages{Source: repository, Ref: ref, Languages: provided}
+	replaced := false
+	for i, source := range lock.Sources {
+		if source.Source == repository && source.Ref == ref {
+			lock.Sources[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lock.Sources = append(lock.Sources, entry)
+	}
+
+	return lock.write(path)
+}
+
+// pullLockedTemplates pulls the languages of a lock which are missing from
+// ./template at the commit they were locked to
+func pullLockedTemplates(lock *templateLock) error {
+	pulled := map[string]bool{}
+
+	for _, language := range sortedLanguages(lock) {
+		locked := lock.Templates[language]
+		if _, err := os.Stat(filepath.Join(templateDirectory, language)); err == nil {
+			continue
+		}
+
+		key := locked.Source + "#" + locked.Commit
+		if pulled[key] {
+			continue
+		}
+		pulled[key] = true
+
+		fmt.Printf("Pulling %s from %s at %s as locked in %s\n", language, locked.Source, locked.Commit, templateLockFile)
+		if err := fetchTemplatesAt(locked.Source, locked.Ref, locked.Commit, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateLockWarnings lists the languages in ./template which differ from
+// the commit they were locked to
+func templateLockWarnings(lock *templateLock) []string {
+	var warnings []string
+
+	for _, language := range sortedLanguages(lock) {
+		locked := lock.Templates[language]
+
+		hash, err := builder.FolderHash(filepath.Join(templateDirectory, language))
+		if err == nil && hash == locked.Hash {
+			continue
+		}
+
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Warning: %s%s is locked in %s but cannot be read: %s", templateDirectory, language, templateLockFile, err))
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("Warning: %s%s differs from %s at %s as locked in %s, run \"faas-cli template pull %s#%s --overwrite\" to restore it",
+			templateDirectory, language, locked.Source, shortCommit(locked.Commit), templateLockFile, locked.Source, locked.Commit))
+	}
+
+	return warnings
+}
+
+// pullBuildTemplates makes sure ./template has the templates to build with.
+// Languages in template.lock are pulled at their locked commit, a source in
+// the stack file which is not locked yet is pulled at its ref, and without
+// either the default repository is pulled when there is no ./template.
+func pullBuildTemplates(sources []stack.TemplateSource) error {
+	lock, err := readTemplateLock(templateLockFile)
+	if err != nil {
+		return err
+	}
+
+	if err := pullLockedTemplates(lock); err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		if lockedSource(lock, source) {
+			continue
+		}
+
+		fmt.Printf("Pulling templates from %s\n", source.Source)
+		// A source whose ref changed replaces the languages it had locked
+		if err := fetchTemplatesAt(source.Source, source.Ref, source.Ref, true); err != nil {
+			return err
+		}
+	}
+
+	if len(lock.Templates) == 0 && len(sources) == 0 {
+		return PullTemplates(DefaultTemplateRepository)
+	}
+
+	lock, err = readTemplateLock(templateLockFile)
+	if err != nil {
+		return err
+	}
+	for _, warning := range templateLockWarnings(lock) {
+		fmt.Println(warning)
+	}
+	return nil
+}
+
+// lockedSource is true when every language of source at its ref is locked
+// to it, a language which was dropped from the lock or since pulled from
+// another source means the source has to be pulled again
+func lockedSource(lock *templateLock, source stack.TemplateSource) bool {
+	for _, entry := range lock.Sources {
+		if entry.Source != source.Source || entry.Ref != source.Ref {
+			continue
+		}
+
+		for _, language := range entry.Languages {
+			locked, ok := lock.Templates[language]
+			if !ok || locked.Source != source.Source || locked.Ref != source.Ref {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sortedLanguages(lock *templateLock) []string {
+	var languages []string
+	for language := range lock.Templates {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,174 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/versioncontrol"
+)
+
+func Test_splitTemplateSource(t *testing.T) {
+	testCases := []struct {
+		source     string
+		repository string
+		ref        string
+	}{
+		{source: "https://github.com/openfaas/templates.git", repository: "https://github.com/openfaas/templates.git"},
+		{source: "https://github.com/openfaas/templates.git#1.2.0", repository: "https://github.com/openfaas/templates.git", ref: "1.2.0"},
+		{source: "git@github.com:openfaas/templates.git#feature/arm64", repository: "git@github.com:openfaas/templates.git", ref: "feature/arm64"},
+		{source: "/tmp/templates#4e38e38", repository: "/tmp/templates", ref: "4e38e38"},
+	}
+
+	for _, testCase := range testCases {
+		repository, ref := splitTemplateSource(testCase.source)
+		if repository != testCase.repository || ref != testCase.ref {
+			t.Errorf("%s: want %q and %q, got %q and %q", testCase.source, testCase.repository, testCase.ref, repository, ref)
+		}
+	}
+}
+
+func Test_fetchTemplates_Lock(t *testing.T) {
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+	defer tearDownFetchTemplates(t)
+
+	commit, err := versioncontrol.GitHeadSHA.Output(localTemplateRepository, nil)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if err := fetchTemplates(localTemplateRepository+"#"+commit, false); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	lock, err := readTemplateLock(templateLockFile)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	locked, ok := lock.Templates["ruby"]
+	if !ok || locked.Source != localTemplateRepository || locked.Ref != commit || locked.Commit != commit || len(locked.Hash) == 0 {
+		t.Fatalf("want ruby locked to %s of %s, got %+v", commit, localTemplateRepository, lock.Templates)
+	}
+
+	if warnings := templateLockWarnings(lock); len(warnings) > 0 {
+		t.Fatalf("want no warnings for the templates as pulled, got %v", warnings)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(templateDirectory, "ruby", "Dockerfile"), []byte("FROM ruby:latest\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	warnings := templateLockWarnings(lock)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "./template/ruby differs from "+localTemplateRepository+" at "+commit[:7]) {
+		t.Fatalf("want a warning that ruby was changed, got %v", warnings)
+	}
+
+	// A build without ./template pulls the locked commit again
+	if err := os.RemoveAll(templateDirectory); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := pullBuildTemplates(nil); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(templateDirectory, "ruby")); err != nil {
+		t.Fatalf("want ruby pulled from the lock: %s", err)
+	}
+	if lock, err = readTemplateLock(templateLockFile); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if warnings := templateLockWarnings(lock); len(warnings) > 0 {
+		t.Fatalf("want no warnings after pulling the locked templates, got %v", warnings)
+	}
+}
+
+func Test_pullBuildTemplates_StackSources(t *testing.T) {
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+	defer tearDownFetchTemplates(t)
+
+	sources := []stack.TemplateSource{{Source: localTemplateRepository}}
+	if err := pullBuildTemplates(sources); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	lock, err := readTemplateLock(templateLockFile)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if locked := lock.Templates["dockerfile"]; locked.Source != localTemplateRepository || len(locked.Commit) != 40 {
+		t.Fatalf("want dockerfile locked from the stack source, got %+v", lock.Templates)
+	}
+}
+
+func Test_pullBuildTemplates_InvalidSources(t *testing.T) {
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+	defer tearDownFetchTemplates(t)
+
+	for _, source := range []stack.TemplateSource{
+		{Source: "--upload-pack=touch /tmp/pwned"},
+		{Source: "not a repository"},
+		{Source: localTemplateRepository, Ref: "--output=/tmp/pwned"},
+	} {
+		if err := pullBuildTemplates([]stack.TemplateSource{source}); err == nil {
+			t.Fatalf("want an error for the template source %+v", source)
+		}
+	}
+
+	if _, err := os.Stat(templateLockFile); err == nil {
+		t.Fatalf("want no templates pulled from an invalid source")
+	}
+}
+
+func Test_pullBuildTemplates_RepullsLanguagesNotLockedToSource(t *testing.T) {
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+	defer tearDownFetchTemplates(t)
+
+	sources := []stack.TemplateSource{{Source: localTemplateRepository}}
+	if err := pullBuildTemplates(sources); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	lock, err := readTemplateLock(templateLockFile)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if len(lock.Sources) != 1 || len(lock.Sources[0].Languages) != len(lock.Templates) {
+		t.Fatalf("want the languages of the source recorded in the lock, got %+v", lock.Sources)
+	}
+
+	// ruby was since pulled from another repository and dockerfile dropped
+	ruby := lock.Templates["ruby"]
+	ruby.Source = "https://github.com/openfaas-incubator/ruby-http.git"
+	lock.Templates["ruby"] = ruby
+	delete(lock.Templates, "dockerfile")
+	if err := lock.write(templateLockFile); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if lockedSource(lock, sources[0]) {
+		t.Fatalf("want the source to be pulled again when its languages are not locked to it")
+	}
+	if err := pullBuildTemplates(sources); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if lock, err = readTemplateLock(templateLockFile); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	for _, language := range []string{"ruby", "dockerfile"} {
+		if locked := lock.Templates[language]; locked.Source != localTemplateRepository {
+			t.Errorf("want %s locked to the stack source again, got %+v", language, locked)
+		}
+	}
+	if !lockedSource(lock, sources[0]) {
+		t.Errorf("want the source locked after pulling it again")
+	}
+}
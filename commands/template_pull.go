@@ -3,9 +3,11 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -31,52 +33,104 @@ func init() {
 
 // templatePullCmd allows the user to fetch a template from a repository
 var templatePullCmd = &cobra.Command{
-	Use: "template pull <repository URL>",
+	Use: "template pull [<repository URL>[#<tag, branch or commit>]]",
 	Args: func(cmd *cobra.Command, args []string) error {
 		msg := fmt.Sprintf(`Must use a supported verb for 'faas-cli template'
 Currently supported verbs: %v`, supportedVerbs)
 
 		if len(args) == 0 {
-			return fmt.Errorf(msg)
+			return errors.New(msg)
 		}
 
 		if args[0] != "pull" {
-			return fmt.Errorf(msg)
+			return errors.New(msg)
 		}
 
 		if len(args) > 1 {
-
-			// assume it is a local repo
-			if _, err := os.Stat(args[1]); err == nil {
-				return nil
-			}
-
-			var validURL = regexp.MustCompile(gitRemoteRepoRegex)
-			if !validURL.MatchString(args[1]) {
-				return fmt.Errorf("The repository URL must be a valid git repo uri")
-			}
+			return validateTemplateSource(splitTemplateSource(args[1]))
 		}
 		return nil
 	},
 	Short: "Downloads templates from the specified github repo",
 	Long: `Downloads the compressed github repo specified by [URL], and extracts the 'template'
-	directory from the root of the repo, if it exists.`,
-	Example: "faas-cli template pull https://github.com/openfaas/faas-cli",
-	Run:     runTemplatePull,
+	directory from the root of the repo, if it exists.
+
+	A tag, branch or commit after a "#" is pulled instead of the default branch.
+	Without a URL the sources in the templates section of the YAML file are
+	pulled, or the official templates when it has none. The commit pulled for
+	each language is recorded in template.lock, which build uses to pull the
+	same templates and to warn when ./template has changed.`,
+	Example: `  faas-cli template pull https://github.com/openfaas/faas-cli
+  faas-cli template pull https://github.com/openfaas/templates.git#1.2.0
+  faas-cli template pull -f stack.yml --overwrite`,
+	Run: runTemplatePull,
+}
+
+// validateTemplateSource checks a repository is a git URL or a local path
+// and that neither it nor the ref could be taken by git as an option
+func validateTemplateSource(repository string, ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid template ref %q", ref)
+	}
+	if strings.HasPrefix(repository, "-") {
+		return fmt.Errorf("The repository URL must be a valid git repo uri")
+	}
+
+	// assume it is a local repo
+	if _, err := os.Stat(repository); err == nil {
+		return nil
+	}
+
+	var validURL = regexp.MustCompile(gitRemoteRepoRegex)
+	if !validURL.MatchString(repository) {
+		return fmt.Errorf("The repository URL must be a valid git repo uri")
+	}
+	return nil
 }
 
 func runTemplatePull(cmd *cobra.Command, args []string) {
-	repository := DefaultTemplateRepository
+	repositories := []string{DefaultTemplateRepository}
 	if len(args) > 1 {
-		repository = args[1]
+		repositories = []string{args[1]}
+	} else if len(yamlFile) > 0 {
+		sources, err := stackTemplateSources()
+		if err != nil {
+			fmt.Println(err)
+
+			os.Exit(1)
+		}
+		if len(sources) > 0 {
+			repositories = sources
+		}
+	}
+
+	for _, repository := range repositories {
+		fmt.Println("Fetch templates from repository: " + repository)
+		if err := fetchTemplates(repository, overwrite); err != nil {
+			fmt.Println(err)
+
+			os.Exit(1)
+		}
 	}
+}
 
-	fmt.Println("Fetch templates from repository: " + repository)
-	if err := fetchTemplates(repository, overwrite); err != nil {
-		fmt.Println(err)
+// stackTemplateSources returns the templates section of the YAML file as
+// repository#ref sources
+func stackTemplateSources() ([]string, error) {
+	services, err := parseStack(stackFiles(), "", "")
+	if err != nil {
+		return nil, err
+	}
 
-		os.Exit(1)
+	var sources []string
+	for _, source := range services.Templates {
+		if len(source.Ref) > 0 {
+			sources = append(sources, source.Source+"#"+source.Ref)
+		} else {
+			sources = append(sources, source.Source)
+		}
 	}
+	return sources, nil
 }
 
 func pullDebugPrint(message string) {
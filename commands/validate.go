@@ -5,8 +5,218 @@ package commands
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 )
 
+// validQuantity matches Kubernetes style quantities such as 128Mi, 0.5 or 100m
+var validQuantity = regexp.MustCompile(`^([0-9]+(\.[0-9]*)?|\.[0-9]+)(m|k|K|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`)
+
+func init() {
+	faasCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   `validate -f YAML_FILE [-f YAML_FILE]... [--regex "REGEX"] [--filter "WILDCARD"]`,
+	Short: "Check a stack file for mistakes",
+	Long: `Checks a stack file for problems which are otherwise found late or ignored:
+unknown fields such as "enviroment:", invalid function names, images without
+a registry or username prefix, memory and CPU quantities which do not parse,
+languages missing from ./template, missing handler folders and environment_file
+entries which cannot be read. Each problem is reported with its file and line.`,
+	Example: `  faas-cli validate -f ./stack.yml
+  faas-cli validate -f ./stack.yml -f ./prod.yml`,
+	RunE: runValidate,
+}
+
+// validationIssue is a problem found in a stack file, Line is 0 when the
+// line is not known
+type validationIssue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (issue validationIssue) String() string {
+	if issue.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", issue.File, issue.Line, issue.Message)
+	}
+	return fmt.Sprintf("%s: %s", issue.File, issue.Message)
+}
+
+// stackFileLines finds the line of a key across the stack file and its
+// overlays, the last file to set a key is where its value came from
+type stackFileLines struct {
+	files []string
+	lines []map[string]int
+}
+
+func (s *stackFileLines) issue(path string, format string, args ...interface{}) validationIssue {
+	message := fmt.Sprintf(format, args...)
+	for i := len(s.files) - 1; i >= 0; i-- {
+		if line, ok := s.lines[i][path]; ok {
+			return validationIssue{File: s.files[i], Line: line, Message: message}
+		}
+	}
+
+	// Fall back to the parent key, such as the function for a missing field
+	if index := strings.LastIndex(path, "."); index > -1 {
+		return s.issue(path[:index], format, args...)
+	}
+	return validationIssue{File: s.files[0], Message: message}
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with --yaml/-f")
+	}
+
+	files := stackFiles()
+	issues, err := validateStack(files)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d problem(s) in %s", len(issues), strings.Join(files, ", "))
+	}
+
+	fmt.Printf("%s is valid\n", strings.Join(files, ", "))
+	return nil
+}
+
+// validateStack checks each file for unknown fields and then checks the
+// merged stack, errors are returned when a file cannot be read or parsed
+func validateStack(files []string) ([]validationIssue, error) {
+	lookup, err := stackLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := &stackFileLines{files: files}
+	var issues []validationIssue
+
+	for _, file := range files {
+		data, err := stack.ReadYAMLFile(file, lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		fileLines := stack.KeyLines(data)
+		lines.lines = append(lines.lines, fileLines)
+
+		unknown, err := stack.FindUnknownFields(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+
+		for _, field := range unknown {
+			message := fmt.Sprintf("unknown field %q", field.Key)
+			if len(field.Suggestion) > 0 {
+				message += fmt.Sprintf(", did you mean %q?", field.Suggestion)
+			}
+			issues = append(issues, validationIssue{File: file, Line: fileLines[field.Path], Message: message})
+		}
+	}
+
+	services, err := parseStack(files, regex, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range services.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	invalidImages := validateImages(services.Functions)
+
+	for _, name := range names {
+		issues = append(issues, validateStackFunction(lines, name, services.Functions[name], invalidImages[name])...)
+	}
+
+	return issues, nil
+}
+
+func validateStackFunction(lines *stackFileLines, name string, function stack.Function, invalidImage error) []validationIssue {
+	var issues []validationIssue
+	path := "functions." + name
+
+	if err := validateFunctionName(name); err != nil {
+		issues = append(issues, lines.issue(path, "invalid function name %q: %s", name, err))
+	}
+
+	if len(function.Image) == 0 {
+		issues = append(issues, lines.issue(path+".image", "function %s has no image", name))
+	} else if invalidImage != nil {
+		issues = append(issues, lines.issue(path+".image", "%s", invalidImage))
+	}
+
+	for _, resources := range []struct {
+		field     string
+		resources *stack.FunctionResources
+	}{
+		{field: "limits", resources: function.Limits},
+		{field: "requests", resources: function.Requests},
+	} {
+		if resources.resources == nil {
+			continue
+		}
+		if memory := resources.resources.Memory; len(memory) > 0 && !validQuantity.MatchString(memory) {
+			issues = append(issues, lines.issue(path+"."+resources.field+".memory", "invalid memory quantity %q, use a value such as 128Mi or 1Gi", memory))
+		}
+		if cpu := resources.resources.CPU; len(cpu) > 0 && !validQuantity.MatchString(cpu) {
+			issues = append(issues, lines.issue(path+"."+resources.field+".cpu", "invalid CPU quantity %q, use a value such as 100m or 0.5", cpu))
+		}
+	}
+
+	if !function.SkipBuild {
+		if len(function.Language) > 0 && strings.ToLower(function.Language) != "dockerfile" {
+			if _, err := os.Stat(templateDirectory + function.Language); err != nil {
+				issues = append(issues, lines.issue(path+".lang", "language %q was not found in %s, run \"faas-cli template pull\"", function.Language, templateDirectory))
+			}
+		}
+
+		if len(function.Handler) > 0 {
+			if info, err := os.Stat(function.Handler); err != nil || !info.IsDir() {
+				issues = append(issues, lines.issue(path+".handler", "handler folder %q was not found", function.Handler))
+			}
+		}
+	}
+
+	for _, platform := range function.Platforms {
+		if _, _, _, err := builder.ParsePlatform(platform); err != nil {
+			issues = append(issues, lines.issue(path+".platforms", "%s", err))
+		}
+	}
+
+	for _, file := range function.EnvironmentFile {
+		data, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = yaml.Unmarshal(data, &stack.EnvironmentFile{})
+		}
+		if err != nil {
+			issues = append(issues, lines.issue(path+".environment_file", "cannot read environment_file %q: %s", file, err))
+		}
+	}
+
+	return issues
+}
+
 func validateLanguageFlag(language string) (string, error) {
 	var err error
 
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +23,73 @@ func TestValidateLanguageFlag_Dockerfile(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_validateStack(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-validate")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "figlet"), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	stackYAML := `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ` + filepath.Join(dir, "figlet") + `
+    image: functions/figlet:0.1
+    enviroment:
+      write_debug: true
+    limits:
+      memory: 128 MB
+      cpu: 100m
+  Bad_Name:
+    lang: not-a-template
+    handler: ` + filepath.Join(dir, "missing") + `
+    image: bad-name
+    environment_file:
+      - ` + filepath.Join(dir, "missing.yml") + `
+`
+	if err := ioutil.WriteFile(stackFile, []byte(stackYAML), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	issues, err := validateStack([]string{stackFile})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	want := []string{
+		stackFile + `:10: unknown field "enviroment", did you mean "environment"?`,
+		stackFile + `:15: invalid function name "Bad_Name": function name can only contain a-z, 0-9 and dashes`,
+		stackFile + `:18: image "bad-name" has no registry or username prefix such as user1/bad-name`,
+		stackFile + `:16: language "not-a-template" was not found in ./template/, run "faas-cli template pull"`,
+		stackFile + `:17: handler folder "` + filepath.Join(dir, "missing") + `" was not found`,
+		stackFile + `:19: cannot read environment_file "` + filepath.Join(dir, "missing.yml") + `"`,
+		stackFile + `:13: invalid memory quantity "128 MB", use a value such as 128Mi or 1Gi`,
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d issues, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("want issue %q, got %q", want[i], got[i])
+		}
+	}
+}
@@ -5,6 +5,8 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"runtime"
 
 	"github.com/morikuni/aec"
@@ -14,36 +16,58 @@ import (
 
 // GitCommit injected at build-time
 var (
-	shortVersion bool
+	shortVersion  bool
+	versionOutput string
 )
 
 func init() {
 	versionCmd.Flags().BoolVar(&shortVersion, "short-version", false, "Just print Git SHA")
+	addOutputFlag(versionCmd, &versionOutput)
 
 	faasCmd.AddCommand(versionCmd)
 }
 
 // versionCmd displays version information
 var versionCmd = &cobra.Command{
-	Use:   "version [--short-version]",
+	Use:   "version [--short-version] [--output table|json|yaml|go-template=TEMPLATE]",
 	Short: "Display the clients version information",
 	Long: fmt.Sprintf(`The version command returns the current clients version information.
 
 This currently consists of the GitSHA from which the client was built.
 - https://github.com/openfaas/faas-cli/tree/%s`, version.GitCommit),
 	Example: `  faas-cli version
-  faas-cli version --short-version`,
-	Run: runVersion,
+  faas-cli version --short-version
+  faas-cli version --output json`,
+	RunE: runVersion,
 }
 
-func runVersion(cmd *cobra.Command, args []string) {
+// versionInfo is the client version printed by the version command
+type versionInfo struct {
+	Version   string `json:"version" yaml:"version"`
+	GitCommit string `json:"gitCommit" yaml:"gitCommit"`
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
 	if shortVersion {
 		fmt.Println(version.BuildVersion())
-	} else {
-		printFiglet()
-		fmt.Printf("Commit: %s\n", version.GitCommit)
-		fmt.Printf("Version: %s\n", version.BuildVersion())
+		return nil
+	}
+
+	format, err := parseOutputFormat(versionOutput)
+	if err != nil {
+		return err
 	}
+
+	info := versionInfo{
+		Version:   version.BuildVersion(),
+		GitCommit: version.GitCommit,
+	}
+
+	return format.print(os.Stdout, info, func(w io.Writer, wide bool) {
+		printFiglet()
+		fmt.Fprintf(w, "Commit: %s\n", info.GitCommit)
+		fmt.Fprintf(w, "Version: %s\n", info.Version)
+	})
 }
 
 func printFiglet() {
@@ -51,7 +75,7 @@ func printFiglet() {
 	if runtime.GOOS == "windows" {
 		figletColoured = aec.GreenF.Apply(figletStr)
 	}
-	fmt.Printf(figletColoured)
+	fmt.Print(figletColoured)
 }
 
 const figletStr = `  ___                   _____           ____
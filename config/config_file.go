@@ -26,13 +26,53 @@ var (
 // ConfigFile for OpenFaaS CLI exclusively.
 type ConfigFile struct {
 	AuthConfigs []AuthConfig `yaml:"auths"`
-	FilePath    string       `yaml:"-"`
+
+	// CredsStore names a docker-credential-* helper such as "osxkeychain" or
+	// "secretservice", gateway secrets are then never written to this file
+	CredsStore string `yaml:"credsStore,omitempty"`
+
+	// Contexts are named gateways, see "faas-cli context"
+	Contexts       []Context `yaml:"contexts,omitempty"`
+	CurrentContext string    `yaml:"current_context,omitempty"`
+
+	FilePath string `yaml:"-"`
 }
 
+// Authentication types which can be set in AuthConfig.Auth
+const (
+	// BasicAuthType stores the base64 encoded "username:password" in Token
+	BasicAuthType = "basic"
+
+	// BearerAuthType stores a static bearer token in Token
+	BearerAuthType = "bearer"
+
+	// OAuth2AuthType uses the OAuth2 client credentials grant, the last access
+	// token is cached in Token until TokenExpiry
+	OAuth2AuthType = "oauth2"
+
+	// ClientCertAuthType authenticates with a TLS client certificate
+	ClientCertAuthType = "client-cert"
+)
+
+// AuthConfig holds the credentials used for a gateway
 type AuthConfig struct {
 	Gateway string `yaml:"gateway,omitempty"`
 	Auth    string `yaml:"auth,omitempty"`
 	Token   string `yaml:"token,omitempty"`
+
+	// TokenExpiry is the RFC3339 expiry time of the cached OAuth2 access token
+	TokenExpiry string `yaml:"token_expiry,omitempty"`
+
+	// OAuth2 client credentials
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	TokenURL     string   `yaml:"token_url,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+
+	// TLS client certificate, CAFile is optional
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	CAFile   string `yaml:"ca_file,omitempty"`
 }
 
 // New initializes a config file for the given file path
@@ -123,6 +163,9 @@ func (configFile *ConfigFile) load() error {
 	if len(conf.AuthConfigs) > 0 {
 		configFile.AuthConfigs = conf.AuthConfigs
 	}
+	configFile.CredsStore = conf.CredsStore
+	configFile.Contexts = conf.Contexts
+	configFile.CurrentContext = conf.CurrentContext
 	return nil
 }
 
@@ -150,11 +193,6 @@ func DecodeAuth(input string) (string, string, error) {
 
 // UpdateAuthConfig creates or updates the username and password for a given gateway
 func UpdateAuthConfig(gateway string, username string, password string) error {
-	_, err := url.ParseRequestURI(gateway)
-	if err != nil || len(gateway) < 1 {
-		return fmt.Errorf("invalid gateway URL")
-	}
-
 	if len(username) < 1 {
 		return fmt.Errorf("username can't be an empty string")
 	}
@@ -163,6 +201,24 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 		return fmt.Errorf("password can't be an empty string")
 	}
 
+	return SaveAuthConfig(AuthConfig{
+		Gateway: gateway,
+		Auth:    BasicAuthType,
+		Token:   EncodeAuth(username, password),
+	})
+}
+
+// SaveAuthConfig creates or replaces the auth config for auth.Gateway
+func SaveAuthConfig(auth AuthConfig) error {
+	_, err := url.ParseRequestURI(auth.Gateway)
+	if err != nil || len(auth.Gateway) < 1 {
+		return fmt.Errorf("invalid gateway URL")
+	}
+
+	if err := validateAuthConfig(auth); err != nil {
+		return err
+	}
+
 	configPath, err := EnsureFile()
 	if err != nil {
 		return err
@@ -177,20 +233,31 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 		return err
 	}
 
-	auth := AuthConfig{
-		Gateway: gateway,
-		Auth:    "basic",
-		Token:   EncodeAuth(username, password),
-	}
-
 	index := -1
 	for i, v := range cfg.AuthConfigs {
-		if gateway == v.Gateway {
+		if auth.Gateway == v.Gateway {
 			index = i
 			break
 		}
 	}
 
+	if len(cfg.CredsStore) > 0 {
+		previous := AuthConfig{Auth: ClientCertAuthType}
+		if index > -1 {
+			previous = cfg.AuthConfigs[index]
+		}
+
+		if auth, err = storeSecret(cfg.CredsStore, auth); err != nil {
+			return err
+		}
+
+		// Don't leave a stale secret behind when switching to an auth type
+		// without one, the helper may not hold it so errors are ignored
+		if inCredsStore(previous) && !inCredsStore(auth) {
+			eraseSecret(cfg.CredsStore, auth.Gateway)
+		}
+	}
+
 	if index == -1 {
 		cfg.AuthConfigs = append(cfg.AuthConfigs, auth)
 	} else {
@@ -204,37 +271,95 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 	return nil
 }
 
-// LookupAuthConfig returns the username and password for a given gateway
-func LookupAuthConfig(gateway string) (string, string, error) {
+func validateAuthConfig(auth AuthConfig) error {
+	switch auth.Auth {
+	case BasicAuthType, BearerAuthType:
+		if len(auth.Token) < 1 {
+			return fmt.Errorf("token can't be an empty string for %s auth", auth.Auth)
+		}
+	case OAuth2AuthType:
+		if len(auth.ClientID) < 1 || len(auth.ClientSecret) < 1 || len(auth.TokenURL) < 1 {
+			return fmt.Errorf("client id, client secret and token URL are required for %s auth", auth.Auth)
+		}
+	case ClientCertAuthType:
+		if len(auth.CertFile) < 1 || len(auth.KeyFile) < 1 {
+			return fmt.Errorf("certificate and key files are required for %s auth", auth.Auth)
+		}
+	default:
+		return fmt.Errorf("unknown auth type: %q", auth.Auth)
+	}
+
+	return nil
+}
+
+// authNotFoundError is returned by LookupAuth when no credentials are saved
+// for a gateway, as opposed to credentials which cannot be read
+type authNotFoundError struct {
+	message string
+}
+
+func (e *authNotFoundError) Error() string {
+	return e.message
+}
+
+// IsAuthNotFound is true for an error from LookupAuth when there is no config
+// file or it has no auth config for the gateway
+func IsAuthNotFound(err error) bool {
+	_, ok := err.(*authNotFoundError)
+	return ok
+}
+
+// LookupAuth returns the auth config saved for a given gateway
+func LookupAuth(gateway string) (*AuthConfig, error) {
 	if !fileExists() {
-		return "", "", fmt.Errorf("config file not found")
+		return nil, &authNotFoundError{"config file not found"}
 	}
 
 	configPath, err := EnsureFile()
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	cfg, err := New(configPath)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	if err := cfg.load(); err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	for _, v := range cfg.AuthConfigs {
 		if gateway == v.Gateway {
-			user, pass, err := DecodeAuth(v.Token)
-			if err != nil {
-				return "", "", err
+			auth := v
+			if len(cfg.CredsStore) > 0 {
+				if err := loadSecret(cfg.CredsStore, &auth); err != nil {
+					return nil, err
+				}
 			}
-			return user, pass, nil
+			return &auth, nil
 		}
 	}
 
-	return "", "", fmt.Errorf("no auth config found for %s", gateway)
+	return nil, &authNotFoundError{fmt.Sprintf("no auth config found for %s", gateway)}
+}
+
+// LookupAuthConfig returns the username and password for a given gateway
+func LookupAuthConfig(gateway string) (string, string, error) {
+	auth, err := LookupAuth(gateway)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(auth.Auth) > 0 && auth.Auth != BasicAuthType {
+		return "", "", fmt.Errorf("auth config for %s uses %s auth, not basic", gateway, auth.Auth)
+	}
+
+	user, pass, err := DecodeAuth(auth.Token)
+	if err != nil {
+		return "", "", err
+	}
+	return user, pass, nil
 }
 
 // RemoveAuthConfig deletes the username and password for a given gateway
@@ -266,6 +391,12 @@ func RemoveAuthConfig(gateway string) error {
 	}
 
 	if index > -1 {
+		if len(cfg.CredsStore) > 0 && inCredsStore(cfg.AuthConfigs[index]) {
+			if err := eraseSecret(cfg.CredsStore, gateway); err != nil {
+				return err
+			}
+		}
+
 		cfg.AuthConfigs = removeAuthByIndex(cfg.AuthConfigs, index)
 		if err := cfg.save(); err != nil {
 			return err
@@ -217,3 +217,46 @@ func Test_RemoveAuthConfig_WithUnknownGateway(t *testing.T) {
 		t.Errorf("Error not matched: %s", err.Error())
 	}
 }
+
+func Test_SaveAuthConfig_BearerToken(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "test-bearer.yml"
+	gatewayURL := "http://openfaas.test"
+
+	err := SaveAuthConfig(AuthConfig{Gateway: gatewayURL, Auth: BearerAuthType, Token: "my-token"})
+	if err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+
+	auth, err := LookupAuth(gatewayURL)
+	if err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+
+	if auth.Auth != BearerAuthType || auth.Token != "my-token" {
+		t.Errorf("got auth %s and token %s, expected %s %s", auth.Auth, auth.Token, BearerAuthType, "my-token")
+	}
+
+	if _, _, err := LookupAuthConfig(gatewayURL); err == nil {
+		t.Errorf("want error looking up username and password for bearer auth")
+	}
+}
+
+func Test_SaveAuthConfig_InvalidAuthConfigs(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "test-invalid.yml"
+	gatewayURL := "http://openfaas.test"
+
+	testCases := []AuthConfig{
+		{Gateway: gatewayURL, Auth: "kerberos", Token: "token"},
+		{Gateway: gatewayURL, Auth: BearerAuthType},
+		{Gateway: gatewayURL, Auth: OAuth2AuthType, ClientID: "id", TokenURL: "http://auth.test"},
+		{Gateway: gatewayURL, Auth: ClientCertAuthType, CertFile: "cert.pem"},
+	}
+
+	for _, testCase := range testCases {
+		if err := SaveAuthConfig(testCase); err == nil {
+			t.Errorf("want error for %#v", testCase)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// Context is a named gateway and the defaults used by commands when it is
+// selected with "faas-cli context use" or --context
+type Context struct {
+	Name    string `yaml:"name"`
+	Gateway string `yaml:"gateway"`
+
+	// Network and ImagePrefix are used when a command is not given one
+	Network     string `yaml:"network,omitempty"`
+	ImagePrefix string `yaml:"image_prefix,omitempty"`
+
+	// Auth is the gateway whose saved credentials are used for this context,
+	// by default the credentials saved for Gateway are used
+	Auth string `yaml:"auth,omitempty"`
+
+	// TLS settings for the gateway, CAFile replaces the system roots
+	TLSCAFile   string `yaml:"tls_ca_file,omitempty"`
+	TLSInsecure bool   `yaml:"tls_insecure,omitempty"`
+}
+
+var validContextName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// SaveContext creates or replaces the context with the same name
+func SaveContext(context Context) error {
+	if !validContextName.MatchString(context.Name) {
+		return fmt.Errorf("invalid context name %q, use letters, digits, '-', '_' or '.'", context.Name)
+	}
+
+	if _, err := url.ParseRequestURI(context.Gateway); err != nil {
+		return fmt.Errorf("invalid gateway URL for context %s", context.Name)
+	}
+
+	cfg, err := ensureConfig()
+	if err != nil {
+		return err
+	}
+
+	index := contextIndex(cfg.Contexts, context.Name)
+	if index == -1 {
+		cfg.Contexts = append(cfg.Contexts, context)
+	} else {
+		cfg.Contexts[index] = context
+	}
+
+	return cfg.save()
+}
+
+// UseContext makes name the current context, an empty name clears it so the
+// gateway is resolved from flags, stack files and OPENFAAS_URL alone
+func UseContext(name string) error {
+	cfg, err := ensureConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(name) > 0 && contextIndex(cfg.Contexts, name) == -1 {
+		return fmt.Errorf("context %s not found", name)
+	}
+
+	cfg.CurrentContext = name
+	return cfg.save()
+}
+
+// RemoveContext deletes a context, the current context is cleared when it
+// is the one removed
+func RemoveContext(name string) error {
+	cfg, err := ensureConfig()
+	if err != nil {
+		return err
+	}
+
+	index := contextIndex(cfg.Contexts, name)
+	if index == -1 {
+		return fmt.Errorf("context %s not found", name)
+	}
+
+	cfg.Contexts = append(cfg.Contexts[:index], cfg.Contexts[index+1:]...)
+	if cfg.CurrentContext == name {
+		cfg.CurrentContext = ""
+	}
+
+	return cfg.save()
+}
+
+// ListContexts returns the saved contexts and the name of the current one
+func ListContexts() ([]Context, string, error) {
+	if !fileExists() {
+		return nil, "", nil
+	}
+
+	cfg, err := ensureConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cfg.Contexts, cfg.CurrentContext, nil
+}
+
+// LookupContext returns the named context, or the current context when name
+// is empty. A nil context is returned when no context is in use.
+func LookupContext(name string) (*Context, error) {
+	contexts, current, err := ListContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(name) == 0 {
+		if len(current) == 0 {
+			return nil, nil
+		}
+		name = current
+	}
+
+	index := contextIndex(contexts, name)
+	if index == -1 {
+		return nil, fmt.Errorf("context %s not found, see \"faas-cli context list\"", name)
+	}
+
+	return &contexts[index], nil
+}
+
+// LookupCurrentContext returns the current context. A current context which
+// has been deleted from the config file is cleared, and its name returned so
+// the caller can warn about it.
+func LookupCurrentContext() (*Context, string, error) {
+	contexts, current, err := ListContexts()
+	if err != nil || len(current) == 0 {
+		return nil, "", err
+	}
+
+	index := contextIndex(contexts, current)
+	if index == -1 {
+		return nil, current, UseContext("")
+	}
+
+	return &contexts[index], "", nil
+}
+
+func ensureConfig() (*ConfigFile, error) {
+	configPath, err := EnsureFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func contextIndex(contexts []Context, name string) int {
+	for i, context := range contexts {
+		if context.Name == name {
+			return i
+		}
+	}
+	return -1
+}
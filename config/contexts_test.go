@@ -0,0 +1,74 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func Test_Contexts(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "contexts.yml"
+
+	if context, err := LookupContext(""); err != nil || context != nil {
+		t.Fatalf("want no current context without a config file, got %+v, %v", context, err)
+	}
+
+	if err := SaveContext(Context{Name: "dev", Gateway: "http://127.0.0.1:8080"}); err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+	if err := SaveContext(Context{Name: "prod", Gateway: "https://gateway.example.com", Network: "func_prod"}); err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+	if err := UpdateAuthConfig("https://gateway.example.com", "admin", "pass"); err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+
+	if err := UseContext("prod"); err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+
+	context, err := LookupContext("")
+	if err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+	if context.Name != "prod" || context.Network != "func_prod" {
+		t.Fatalf("want the prod context, got %+v", context)
+	}
+
+	if _, _, err := LookupAuthConfig("https://gateway.example.com"); err != nil {
+		t.Fatalf("want auth configs to be kept next to contexts, got error %s", err.Error())
+	}
+
+	if err := RemoveContext("prod"); err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+
+	contexts, current, err := ListContexts()
+	if err != nil {
+		t.Fatalf("got error %s", err.Error())
+	}
+	if len(contexts) != 1 || contexts[0].Name != "dev" || len(current) > 0 {
+		t.Fatalf("want only dev and no current context, got %+v and %q", contexts, current)
+	}
+}
+
+func Test_Contexts_Invalid(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "contexts-invalid.yml"
+
+	if err := SaveContext(Context{Name: "", Gateway: "http://127.0.0.1:8080"}); err == nil {
+		t.Errorf("want an error for an empty name")
+	}
+	if err := SaveContext(Context{Name: "dev", Gateway: "127.0.0.1"}); err == nil {
+		t.Errorf("want an error for an invalid gateway")
+	}
+	if err := UseContext("missing"); err == nil {
+		t.Errorf("want an error for an unknown context")
+	}
+	if _, err := LookupContext("missing"); err == nil {
+		t.Errorf("want an error for an unknown context")
+	}
+}
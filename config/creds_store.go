@@ -0,0 +1,172 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+// bearerTokenUsername is stored as the helper username for bearer tokens,
+// matching the convention Docker uses for identity tokens
+const bearerTokenUsername = "<token>"
+
+// credsStoreProgram returns the docker-credential-* helper for the store name
+func credsStoreProgram(credsStore string) client.ProgramFunc {
+	return client.NewShellProgramFunc("docker-credential-" + credsStore)
+}
+
+// CredsStore returns the credential helper configured in the faas-cli config,
+// an empty string means secrets are kept in the config file
+func CredsStore() string {
+	if !fileExists() {
+		return ""
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return ""
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return ""
+	}
+
+	if err := cfg.load(); err != nil {
+		return ""
+	}
+
+	return cfg.CredsStore
+}
+
+// inCredsStore returns true when the secret for a saved auth config is kept in
+// the credential helper. Entries saved before the helper was configured still
+// hold their secret in the config file.
+func inCredsStore(auth AuthConfig) bool {
+	switch auth.Auth {
+	case "", BasicAuthType, BearerAuthType:
+		return len(auth.Token) == 0
+	case OAuth2AuthType:
+		return len(auth.ClientSecret) == 0
+	default:
+		return false
+	}
+}
+
+// storeSecret moves the secret out of auth into the credential helper, the
+// returned config only holds the fields which are safe to write to disk
+func storeSecret(credsStore string, auth AuthConfig) (AuthConfig, error) {
+	creds := &credentials.Credentials{
+		ServerURL: auth.Gateway,
+	}
+
+	switch auth.Auth {
+	case "", BasicAuthType:
+		username, password, err := DecodeAuth(auth.Token)
+		if err != nil {
+			return auth, err
+		}
+		creds.Username = username
+		creds.Secret = password
+		auth.Token = ""
+	case BearerAuthType:
+		creds.Username = bearerTokenUsername
+		creds.Secret = auth.Token
+		auth.Token = ""
+	case OAuth2AuthType:
+		creds.Username = auth.ClientID
+		creds.Secret = auth.ClientSecret
+		auth.ClientSecret = ""
+		// The access token has its own entry, its expiry is not a secret so
+		// it stays in the config file
+		if err := storeOAuth2Token(credsStore, auth.Gateway, auth.Token); err != nil {
+			return auth, err
+		}
+		if len(auth.Token) == 0 {
+			auth.TokenExpiry = ""
+		}
+		auth.Token = ""
+	default:
+		return auth, nil
+	}
+
+	if err := client.Store(credsStoreProgram(credsStore), creds); err != nil {
+		return auth, fmt.Errorf("cannot store credentials for %s in %s: %s", auth.Gateway, credsStore, err)
+	}
+
+	return auth, nil
+}
+
+// loadSecret fills in the secret for auth from the credential helper
+func loadSecret(credsStore string, auth *AuthConfig) error {
+	if !inCredsStore(*auth) {
+		return nil
+	}
+
+	creds, err := client.Get(credsStoreProgram(credsStore), auth.Gateway)
+	if err != nil {
+		if credentials.IsErrCredentialsNotFound(err) {
+			return fmt.Errorf("no credentials found for %s in %s", auth.Gateway, credsStore)
+		}
+		return fmt.Errorf("cannot get credentials for %s from %s: %s", auth.Gateway, credsStore, err)
+	}
+
+	switch auth.Auth {
+	case "", BasicAuthType:
+		auth.Token = EncodeAuth(creds.Username, creds.Secret)
+	case BearerAuthType:
+		auth.Token = creds.Secret
+	case OAuth2AuthType:
+		auth.ClientSecret = creds.Secret
+
+		token, err := client.Get(credsStoreProgram(credsStore), oauth2TokenURL(auth.Gateway))
+		if err == nil {
+			auth.Token = token.Secret
+		} else if !credentials.IsErrCredentialsNotFound(err) {
+			return fmt.Errorf("cannot get the access token for %s from %s: %s", auth.Gateway, credsStore, err)
+		}
+	}
+
+	return nil
+}
+
+// oauth2TokenURL is the helper entry of the cached access token of an OAuth2
+// gateway, the entry of the gateway itself holds the client secret
+func oauth2TokenURL(gateway string) string {
+	return gateway + "#oauth2-token"
+}
+
+// storeOAuth2Token caches an access token in the credential helper, an empty
+// token removes the cached one
+func storeOAuth2Token(credsStore string, gateway string, token string) error {
+	if len(token) == 0 {
+		// The helper may not hold a token so errors are ignored
+		client.Erase(credsStoreProgram(credsStore), oauth2TokenURL(gateway))
+		return nil
+	}
+
+	creds := &credentials.Credentials{
+		ServerURL: oauth2TokenURL(gateway),
+		Username:  bearerTokenUsername,
+		Secret:    token,
+	}
+	if err := client.Store(credsStoreProgram(credsStore), creds); err != nil {
+		return fmt.Errorf("cannot store the access token for %s in %s: %s", gateway, credsStore, err)
+	}
+	return nil
+}
+
+// eraseSecret removes the secret for gateway from the credential helper
+func eraseSecret(credsStore string, gateway string) error {
+	// Only OAuth2 gateways have a cached access token
+	client.Erase(credsStoreProgram(credsStore), oauth2TokenURL(gateway))
+
+	if err := client.Erase(credsStoreProgram(credsStore), gateway); err != nil {
+		return fmt.Errorf("cannot erase credentials for %s from %s: %s", gateway, credsStore, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeHelper keeps each credential in a file next to the script named by a
+// checksum of its server URL
+const fakeHelper = `#!/bin/sh
+entry() {
+	echo "$(dirname "$0")/creds-$(printf '%s' "$1" | cksum | cut -d' ' -f1).json"
+}
+case "$1" in
+store)
+	data="$(cat)"
+	url="$(printf '%s' "$data" | sed -n 's/.*"ServerURL":"\([^"]*\)".*/\1/p')"
+	printf '%s' "$data" > "$(entry "$url")" ;;
+get)
+	store="$(entry "$(cat)")"
+	if [ ! -f "$store" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	cat "$store" ;;
+erase) rm -f "$(entry "$(cat)")" ;;
+*) exit 1 ;;
+esac
+`
+
+// setupCredsStore writes a config file using the fake helper and puts the
+// helper on the PATH, the returned func restores the PATH
+func setupCredsStore(t *testing.T, file string) (string, func()) {
+	dir, err := ioutil.TempDir("", "faas-cli-creds-store-test")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	helperPath := filepath.Join(dir, "docker-credential-faas-test")
+	if err := ioutil.WriteFile(helperPath, []byte(fakeHelper), 0700); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	DefaultDir = dir
+	DefaultFile = file
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("credsStore: faas-test\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+	return dir, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_CredsStore_BasicAuth(t *testing.T) {
+	dir, cleanup := setupCredsStore(t, "creds-store-basic.yml")
+	defer cleanup()
+
+	gatewayURL := "http://openfaas.test"
+	if err := UpdateAuthConfig(gatewayURL, "admin", "s3cr3t"); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "creds-store-basic.yml"))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if strings.Contains(string(data), "token") {
+		t.Errorf("want no secret in the config file, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "credsStore: faas-test") {
+		t.Errorf("want credsStore to be kept in the config file, got:\n%s", data)
+	}
+
+	user, pass, err := LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if user != "admin" || pass != "s3cr3t" {
+		t.Errorf("got user %s and pass %s, expected admin s3cr3t", user, pass)
+	}
+
+	if err := RemoveAuthConfig(gatewayURL); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, "creds-*.json")); len(entries) > 0 {
+		t.Errorf("want the helper to have erased the credentials, got %v", entries)
+	}
+}
+
+func Test_CredsStore_OAuth2(t *testing.T) {
+	dir, cleanup := setupCredsStore(t, "creds-store-oauth2.yml")
+	defer cleanup()
+
+	gatewayURL := "http://openfaas.test"
+	err := SaveAuthConfig(AuthConfig{
+		Gateway:      gatewayURL,
+		Auth:         OAuth2AuthType,
+		ClientID:     "faas-cli",
+		ClientSecret: "client-s3cr3t",
+		TokenURL:     "http://auth.test/token",
+		Token:        "access-token",
+		TokenExpiry:  "2030-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "creds-store-oauth2.yml"))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	for _, secret := range []string{"client-s3cr3t", "access-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("want %s not to be in the config file, got:\n%s", secret, data)
+		}
+	}
+
+	auth, err := LookupAuth(gatewayURL)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth.ClientID != "faas-cli" || auth.ClientSecret != "client-s3cr3t" {
+		t.Errorf("got client id %s and secret %s, expected faas-cli client-s3cr3t", auth.ClientID, auth.ClientSecret)
+	}
+	if auth.Token != "access-token" || auth.TokenExpiry != "2030-01-01T00:00:00Z" {
+		t.Errorf("want the cached access token from the helper, got %s expiring %s", auth.Token, auth.TokenExpiry)
+	}
+
+	// A refreshed token replaces the cached one
+	auth.Token = "refreshed-token"
+	if err := SaveAuthConfig(*auth); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth, err = LookupAuth(gatewayURL); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth.Token != "refreshed-token" || auth.ClientSecret != "client-s3cr3t" {
+		t.Errorf("want the refreshed token and the client secret, got %s and %s", auth.Token, auth.ClientSecret)
+	}
+
+	if CredsStore() != "faas-test" {
+		t.Errorf("want credsStore faas-test, got %q", CredsStore())
+	}
+}
+
+func Test_CredsStore_ExistingSecretOnDisk(t *testing.T) {
+	dir, cleanup := setupCredsStore(t, "creds-store-existing.yml")
+	defer cleanup()
+
+	config := "credsStore: faas-test\nauths:\n- gateway: http://openfaas.test\n  auth: basic\n  token: " + EncodeAuth("admin", "pass") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "creds-store-existing.yml"), []byte(config), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	user, pass, err := LookupAuthConfig("http://openfaas.test")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if user != "admin" || pass != "pass" {
+		t.Errorf("got user %s and pass %s, expected admin pass", user, pass)
+	}
+}
+
+func Test_CredsStore_NotFound(t *testing.T) {
+	dir, cleanup := setupCredsStore(t, "creds-store-missing.yml")
+	defer cleanup()
+
+	config := "credsStore: faas-test\nauths:\n- gateway: http://openfaas.test\n  auth: basic\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "creds-store-missing.yml"), []byte(config), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	_, err := LookupAuth("http://openfaas.test")
+	if err == nil {
+		t.Fatalf("want error when the helper has no credentials")
+	}
+	if !strings.Contains(err.Error(), "no credentials found for http://openfaas.test in faas-test") {
+		t.Errorf("Error not matched: %s", err)
+	}
+}
@@ -4,18 +4,175 @@
 package proxy
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/openfaas/faas-cli/config"
 )
 
-//SetAuth sets basic auth for the given gateway
-func SetAuth(req *http.Request, gateway string) {
-	username, password, err := config.LookupAuthConfig(gateway)
+// ClientAuth authenticates requests sent to the gateway
+type ClientAuth interface {
+	Set(req *http.Request) error
+}
+
+// TLSClientAuth is implemented by providers which authenticate at the transport
+// level, NewClient uses the TLS config when no transport is given.
+type TLSClientAuth interface {
+	ClientAuth
+	TLSConfig() (*tls.Config, error)
+}
+
+// BasicAuth authenticates requests with a fixed username and password
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Set adds the basic auth header to the request
+func (auth *BasicAuth) Set(req *http.Request) error {
+	req.SetBasicAuth(auth.Username, auth.Password)
+	return nil
+}
+
+// BearerTokenAuth authenticates requests with a static bearer token
+type BearerTokenAuth struct {
+	Token string
+}
+
+// Set adds the bearer token to the request
+func (auth *BearerTokenAuth) Set(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+auth.Token)
+	return nil
+}
+
+// ClientCertAuth authenticates with a TLS client certificate
+type ClientCertAuth struct {
+	CertFile string
+	KeyFile  string
+
+	// CAFile optionally replaces the system roots used to verify the gateway
+	CAFile string
+}
+
+// Set leaves the request untouched, the certificate is presented by the transport
+func (auth *ClientCertAuth) Set(req *http.Request) error {
+	return nil
+}
+
+// TLSConfig loads the client certificate and CA bundle
+func (auth *ClientCertAuth) TLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load client certificate: %s", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if len(auth.CAFile) > 0 {
+		if tlsConfig.RootCAs, err = loadCAFile(auth.CAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// tlsSettingsAuth adds gateway TLS settings to another provider
+type tlsSettingsAuth struct {
+	auth               ClientAuth
+	caFile             string
+	insecureSkipVerify bool
+}
+
+// WithTLSSettings returns a provider which authenticates with auth, which may
+// be nil, and verifies the gateway with caFile or not at all when
+// insecureSkipVerify is set
+func WithTLSSettings(auth ClientAuth, caFile string, insecureSkipVerify bool) ClientAuth {
+	return &tlsSettingsAuth{auth: auth, caFile: caFile, insecureSkipVerify: insecureSkipVerify}
+}
+
+// Set authenticates the request with the wrapped provider
+func (auth *tlsSettingsAuth) Set(req *http.Request) error {
+	if auth.auth == nil {
+		return nil
+	}
+	return auth.auth.Set(req)
+}
+
+// TLSConfig extends the TLS config of the wrapped provider
+func (auth *tlsSettingsAuth) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if tlsAuth, ok := auth.auth.(TLSClientAuth); ok {
+		var err error
+		if tlsConfig, err = tlsAuth.TLSConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(auth.caFile) > 0 {
+		var err error
+		if tlsConfig.RootCAs, err = loadCAFile(auth.caFile); err != nil {
+			return nil, err
+		}
+	}
+	tlsConfig.InsecureSkipVerify = auth.insecureSkipVerify
+
+	return tlsConfig, nil
+}
+
+func loadCAFile(caFile string) (*x509.CertPool, error) {
+	caBytes, err := ioutil.ReadFile(caFile)
 	if err != nil {
-		// no auth info found
-		return
+		return nil, fmt.Errorf("cannot read CA file: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in CA file: %s", caFile)
+	}
+
+	return pool, nil
+}
+
+// NewAuth returns the provider selected by authConfig.Auth, an empty type is
+// treated as basic auth for configs written by earlier versions
+func NewAuth(authConfig *config.AuthConfig) (ClientAuth, error) {
+	switch authConfig.Auth {
+	case "", config.BasicAuthType:
+		username, password, err := config.DecodeAuth(authConfig.Token)
+		if err != nil {
+			return nil, err
+		}
+		return &BasicAuth{Username: username, Password: password}, nil
+	case config.BearerAuthType:
+		return &BearerTokenAuth{Token: authConfig.Token}, nil
+	case config.OAuth2AuthType:
+		return newOAuth2Auth(authConfig)
+	case config.ClientCertAuthType:
+		return &ClientCertAuth{
+			CertFile: authConfig.CertFile,
+			KeyFile:  authConfig.KeyFile,
+			CAFile:   authConfig.CAFile,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth type %q for gateway %s", authConfig.Auth, authConfig.Gateway)
+	}
+}
+
+// NewCLIAuth returns the provider for the credentials saved by "faas-cli login",
+// a nil ClientAuth is returned when the gateway has no saved credentials
+func NewCLIAuth(gateway string) (ClientAuth, error) {
+	authConfig, err := config.LookupAuth(gateway)
+	if config.IsAuthNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
 
-	req.SetBasicAuth(username, password)
+	return NewAuth(authConfig)
 }
@@ -4,7 +4,9 @@
 package proxy
 
 import (
+	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -13,7 +15,7 @@ import (
 	"github.com/openfaas/faas-cli/config"
 )
 
-func Test_SetAuth_AuthorizationHeader(t *testing.T) {
+func Test_NewCLIAuth_AuthorizationHeader(t *testing.T) {
 	//setup store
 	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
 	config.DefaultFile = "authtest1.yml"
@@ -21,8 +23,15 @@ func Test_SetAuth_AuthorizationHeader(t *testing.T) {
 	openURL := "http://openfaas.test/"
 	config.UpdateAuthConfig(basicAuthURL, "Aladdin", "open sesame")
 
+	auth, err := NewCLIAuth(basicAuthURL)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
 	req, _ := http.NewRequest("GET", openURL, nil)
-	SetAuth(req, basicAuthURL)
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
 	header := req.Header.Get("Authorization")
 	expected := "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ=="
 	if header != expected {
@@ -30,18 +39,149 @@ func Test_SetAuth_AuthorizationHeader(t *testing.T) {
 	}
 }
 
-func Test_SetAuth_SkipAuthorization(t *testing.T) {
+func Test_NewCLIAuth_SkipAuthorization(t *testing.T) {
 	//setup store
 	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
 	config.DefaultFile = "authtest2.yml"
 	basicAuthURL := strings.TrimRight("http://openfaas.test/", "/")
-	openURL := "http://openfaas.test2/"
 	config.UpdateAuthConfig(basicAuthURL, "Aladdin", "open sesame")
 
-	req, _ := http.NewRequest("GET", openURL, nil)
-	SetAuth(req, "http://openfaas.test2")
+	auth, err := NewCLIAuth("http://openfaas.test2")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth != nil {
+		t.Errorf("got %T for a gateway without saved credentials, want none", auth)
+	}
+}
+
+func Test_BasicAuth_Set(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://openfaas.test/", nil)
+
+	auth := &BasicAuth{Username: "Aladdin", Password: "open sesame"}
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
 	header := req.Header.Get("Authorization")
-	if header != "" {
-		t.Errorf("got header %q, want none", header)
+	expected := "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ=="
+	if header != expected {
+		t.Errorf("got header %q, want %q", header, expected)
+	}
+}
+
+func Test_BearerTokenAuth_Set(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://openfaas.test/", nil)
+
+	auth := &BearerTokenAuth{Token: "my-token"}
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	header := req.Header.Get("Authorization")
+	expected := "Bearer my-token"
+	if header != expected {
+		t.Errorf("got header %q, want %q", header, expected)
+	}
+}
+
+func Test_NewAuth_SelectsProvider(t *testing.T) {
+	testCases := []struct {
+		authConfig config.AuthConfig
+		want       string
+	}{
+		{authConfig: config.AuthConfig{Token: config.EncodeAuth("user", "pass")}, want: "*proxy.BasicAuth"},
+		{authConfig: config.AuthConfig{Auth: config.BasicAuthType, Token: config.EncodeAuth("user", "pass")}, want: "*proxy.BasicAuth"},
+		{authConfig: config.AuthConfig{Auth: config.BearerAuthType, Token: "token"}, want: "*proxy.BearerTokenAuth"},
+		{authConfig: config.AuthConfig{Auth: config.OAuth2AuthType, ClientID: "id", ClientSecret: "secret", TokenURL: "http://auth.test/token"}, want: "*proxy.OAuth2ClientCredentialsAuth"},
+		{authConfig: config.AuthConfig{Auth: config.ClientCertAuthType, CertFile: "cert.pem", KeyFile: "key.pem"}, want: "*proxy.ClientCertAuth"},
+	}
+
+	for _, testCase := range testCases {
+		auth, err := NewAuth(&testCase.authConfig)
+		if err != nil {
+			t.Fatalf("Error returned for %q: %s", testCase.authConfig.Auth, err)
+		}
+
+		if got := fmt.Sprintf("%T", auth); got != testCase.want {
+			t.Errorf("auth type %q: want %s, got %s", testCase.authConfig.Auth, testCase.want, got)
+		}
+	}
+}
+
+func Test_NewAuth_UnknownType(t *testing.T) {
+	_, err := NewAuth(&config.AuthConfig{Gateway: "http://openfaas.test", Auth: "kerberos"})
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
+}
+
+func Test_NewCLIAuth_Errors(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
+	config.DefaultFile = "authtest-errors.yml"
+	gateway := "http://openfaas.test"
+
+	if auth, err := NewCLIAuth(gateway); err != nil || auth != nil {
+		t.Fatalf("want no auth without a config file, got %v, %v", auth, err)
+	}
+
+	if err := config.UpdateAuthConfig("http://other.test", "admin", "pass"); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth, err := NewCLIAuth(gateway); err != nil || auth != nil {
+		t.Fatalf("want no auth for a gateway without credentials, got %v, %v", auth, err)
+	}
+
+	path := filepath.Join(config.DefaultDir, config.DefaultFile)
+	if err := ioutil.WriteFile(path, []byte("auths: [\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := NewCLIAuth(gateway); err == nil {
+		t.Fatalf("want an error for a corrupt config file")
+	}
+
+	data := "credsStore: missing-helper\nauths:\n- gateway: http://openfaas.test\n  auth: basic\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := NewCLIAuth(gateway); err == nil {
+		t.Fatalf("want an error when the credentials helper fails")
+	}
+}
+
+func Test_ClientCertAuth_MissingFiles(t *testing.T) {
+	auth := &ClientCertAuth{CertFile: "missing-cert.pem", KeyFile: "missing-key.pem"}
+
+	_, err := NewClient(auth, "https://openfaas.test", nil, nil)
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
+
+	if !strings.Contains(err.Error(), "cannot load client certificate") {
+		t.Fatalf("Error not matched: %s", err)
+	}
+}
+
+func Test_WithTLSSettings(t *testing.T) {
+	auth := WithTLSSettings(&BearerTokenAuth{Token: "abc"}, "", true)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:8080", nil)
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Fatalf("want the wrapped provider to set the header, got %q", got)
+	}
+
+	tlsConfig, err := auth.(TLSClientAuth).TLSConfig()
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Fatalf("want certificate verification to be skipped")
+	}
+
+	if _, err := WithTLSSettings(nil, "/does/not/exist.pem", false).(TLSClientAuth).TLSConfig(); err == nil {
+		t.Fatalf("want an error for a missing CA file")
 	}
 }
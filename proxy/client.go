@@ -0,0 +1,151 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUnauthorized is returned when the gateway rejects the credentials sent with a request
+var ErrUnauthorized = errors.New(`unauthorized access, run "faas-cli login" to setup authentication for this server`)
+
+// StatusError is returned when the gateway responds with an unexpected HTTP status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned unexpected status code: %d - %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound returns true when err was caused by the gateway responding with 404 Not Found
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// Client is an API client for an OpenFaaS gateway. Its methods never write to
+// the terminal, all outcomes are returned as typed results or errors.
+type Client struct {
+	// GatewayURL is the base URL of the gateway without a trailing slash
+	GatewayURL string
+
+	auth       ClientAuth
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for the given gateway. auth may be nil for gateways
+// without authentication, a nil transport selects the default transport and a
+// nil timeout disables the client-side timeout.
+func NewClient(auth ClientAuth, gatewayURL string, transport http.RoundTripper, timeout *time.Duration) (*Client, error) {
+	httpClient := MakeHTTPClient(timeout)
+	if transport != nil {
+		httpClient.Transport = transport
+	} else if tlsAuth, ok := auth.(TLSClientAuth); ok {
+		tlsConfig, err := tlsAuth.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		tr, ok := httpClient.Transport.(*http.Transport)
+		if !ok {
+			tr = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+		tr.TLSClientConfig = tlsConfig
+		httpClient.Transport = tr
+	}
+
+	return &Client{
+		GatewayURL: strings.TrimRight(gatewayURL, "/"),
+		auth:       auth,
+		httpClient: &httpClient,
+	}, nil
+}
+
+// newRequest builds an authenticated request for a path on the gateway
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.GatewayURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL)
+	}
+	req = req.WithContext(ctx)
+
+	if c.auth != nil {
+		if err := c.auth.Set(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// do sends the request, the caller is responsible for closing the response body
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		// Report cancellation and deadlines as-is so callers can tell them apart
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL)
+	}
+
+	return res, nil
+}
+
+// getJSON sends a GET request for path and decodes a 200 OK response into out
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		bytesOut, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL)
+		}
+
+		jsonErr := json.Unmarshal(bytesOut, out)
+		if jsonErr != nil {
+			return fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL, jsonErr.Error())
+		}
+	default:
+		return statusError(res)
+	}
+
+	return nil
+}
+
+// statusError converts a non-successful response into an error
+func statusError(res *http.Response) error {
+	if res.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	bytesOut, _ := ioutil.ReadAll(res.Body)
+	return &StatusError{
+		StatusCode: res.StatusCode,
+		Body:       strings.TrimSpace(string(bytesOut)),
+	}
+}
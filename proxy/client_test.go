@@ -0,0 +1,41 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_Client_ContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer s.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.List(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("want: %s, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func Test_Client_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client, _ := NewClient(nil, "http://127.0.0.1:8080", nil, nil)
+	err := client.Delete(ctx, "function")
+	if err != context.Canceled {
+		t.Fatalf("want: %s, got: %v", context.Canceled, err)
+	}
+}
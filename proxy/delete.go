@@ -5,58 +5,38 @@ package proxy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strings"
 
 	"github.com/openfaas/faas/gateway/requests"
 )
 
-// DeleteFunction delete a function from the FaaS server
-func DeleteFunction(gateway string, functionName string) error {
-	gateway = strings.TrimRight(gateway, "/")
+// Delete removes a function from the gateway, a *StatusError satisfying
+// IsNotFound is returned when the function does not exist.
+func (c *Client) Delete(ctx context.Context, functionName string) error {
 	delReq := requests.DeleteFunctionRequest{FunctionName: functionName}
 	reqBytes, _ := json.Marshal(&delReq)
-	reader := bytes.NewReader(reqBytes)
 
-	c := http.Client{}
-	req, err := http.NewRequest("DELETE", gateway+"/system/functions", reader)
+	req, err := c.newRequest(ctx, http.MethodDelete, "/system/functions", bytes.NewReader(reqBytes))
 	if err != nil {
-		fmt.Println(err)
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	SetAuth(req, gateway)
-	delRes, delErr := c.Do(req)
 
-	if delErr != nil {
-		fmt.Printf("Error removing existing function: %s, gateway=%s, functionName=%s\n", delErr.Error(), gateway, functionName)
-		return delErr
+	res, err := c.do(req)
+	if err != nil {
+		return err
 	}
 
-	if delRes.Body != nil {
-		defer delRes.Body.Close()
+	if res.Body != nil {
+		defer res.Body.Close()
 	}
 
-	switch delRes.StatusCode {
+	switch res.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		fmt.Println("Removing old function.")
-	case http.StatusNotFound:
-		fmt.Println("No existing function to remove")
-	case http.StatusUnauthorized:
-		fmt.Println("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil
 	default:
-		var bodyReadErr error
-		bytesOut, bodyReadErr := ioutil.ReadAll(delRes.Body)
-		if bodyReadErr != nil {
-			err = bodyReadErr
-		} else {
-			err = fmt.Errorf("server returned unexpected status code %d %s", delRes.StatusCode, string(bytesOut))
-			fmt.Println("Server returned unexpected status code", delRes.StatusCode, string(bytesOut))
-		}
+		return statusError(res)
 	}
-
-	return err
 }
@@ -4,9 +4,8 @@
 package proxy
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-
 	"testing"
 
 	"regexp"
@@ -18,13 +17,9 @@ func Test_DeleteFunction(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusOK)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
-
-	r := regexp.MustCompile(`(?m:Removing old function.)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s, got: %s", "Removing old function", stdout)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	if err := client.Delete(context.Background(), "function-to-delete"); err != nil {
+		t.Fatalf("Error returned: %s", err)
 	}
 }
 
@@ -32,13 +27,21 @@ func Test_DeleteFunction_404(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusNotFound)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	err := client.Delete(context.Background(), "function-to-delete")
+	if !IsNotFound(err) {
+		t.Fatalf("Want: not found error, got: %v", err)
+	}
+}
 
-	r := regexp.MustCompile(`(?m:No existing function to remove)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s, got: %s", "No existing function to remove", stdout)
+func Test_DeleteFunction_Unauthorized(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusUnauthorized)
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	err := client.Delete(context.Background(), "function-to-delete")
+	if err != ErrUnauthorized {
+		t.Fatalf("Want: %s, got: %v", ErrUnauthorized, err)
 	}
 }
 
@@ -46,26 +49,28 @@ func Test_DeleteFunction_Not2xxAnd404(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusInternalServerError)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	err := client.Delete(context.Background(), "function-to-delete")
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
 
-	r := regexp.MustCompile(`(?m:Server returned unexpected status code)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Output not matched: %s", stdout)
+	r := regexp.MustCompile(`(?m:server returned unexpected status code)`)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
 	}
 }
 
 func Test_DeleteFunction_MissingURLPrefix(t *testing.T) {
-	url := "127.0.0.1:8080"
-
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(url, "function-to-delete")
-	})
+	client, _ := NewClient(nil, "127.0.0.1:8080", nil, nil)
+	err := client.Delete(context.Background(), "function-to-delete")
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
 
-	expectedErrMsg := "first path segment in URL cannot contain colon"
-	r := regexp.MustCompile(fmt.Sprintf("(?m:%s)", expectedErrMsg))
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, stdout)
+	expectedErrMsg := "cannot connect to OpenFaaS on URL:"
+	r := regexp.MustCompile(expectedErrMsg)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, err.Error())
 	}
 }
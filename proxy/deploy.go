@@ -5,12 +5,10 @@ package proxy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas/gateway/requests"
@@ -22,76 +20,130 @@ type FunctionResourceRequest struct {
 	Requests *stack.FunctionResources
 }
 
-// DeployFunction first tries to deploy a function and if it exists will then attempt
-// a rolling update. Warnings are suppressed for the second API call (if required.)
-func DeployFunction(fprocess string, gateway string, functionName string, image string,
-	registryAuth string, language string, replace bool, envVars map[string]string,
-	network string, constraints []string, update bool, secrets []string,
-	labels map[string]string, functionResourceRequest1 FunctionResourceRequest) {
+// DeployFunctionSpec defines the function to be deployed to the gateway
+type DeployFunctionSpec struct {
+	FProcess     string
+	FunctionName string
+	Image        string
+	RegistryAuth string
+	Language     string
 
-	rollingUpdateInfo := fmt.Sprintf("Function %s already exists, attempting rolling-update.", functionName)
-	warnInsecureGateway := true
-	statusCode, deployOutput := Deploy(fprocess, gateway, functionName, image, registryAuth, language, replace, envVars, network, constraints, update, secrets, labels, functionResourceRequest1, warnInsecureGateway)
+	// Replace removes an existing function before creating it again
+	Replace bool
 
-	warnInsecureGateway = false
-	if update == true && statusCode == http.StatusNotFound {
-		// Re-run the function with update=false
+	// Update performs a rolling update if the function already exists
+	Update bool
 
-		_, deployOutput = Deploy(fprocess, gateway, functionName, image, registryAuth, language, replace, envVars, network, constraints, false, secrets, labels, functionResourceRequest1, warnInsecureGateway)
-	} else if statusCode == http.StatusOK {
-		fmt.Println(rollingUpdateInfo)
-	}
-	fmt.Println()
-	fmt.Println(deployOutput)
+	EnvVars     map[string]string
+	Network     string
+	Constraints []string
+	Secrets     []string
+	Labels      map[string]string
+
+	FunctionResourceRequest FunctionResourceRequest
 }
 
-// Deploy a function to an OpenFaaS gateway over REST
-func Deploy(fprocess string, gateway string, functionName string, image string,
-	registryAuth string, language string, replace bool, envVars map[string]string,
-	network string, constraints []string, update bool, secrets []string,
-	labels map[string]string, functionResourceRequest1 FunctionResourceRequest,
-	warnInsecureGateway bool) (int, string) {
-
-	var deployOutput string
-	// Need to alter Gateway to allow nil/empty string as fprocess, to avoid this repetition.
-	var fprocessTemplate string
-	if len(fprocess) > 0 {
-		fprocessTemplate = fprocess
+// DeployResult describes a successful deployment
+type DeployResult struct {
+	// Status is the HTTP status line returned by the gateway, i.e. "202 Accepted"
+	Status     string
+	StatusCode int
+
+	// RollingUpdate is true when an existing function was updated in place
+	RollingUpdate bool
+
+	// URL which the function can be invoked on
+	URL string
+}
+
+// Deploy creates a function on the gateway. When spec.Replace is set any existing
+// function is removed first, when spec.Update is set a rolling update is attempted
+// and the function is created if it does not exist yet.
+func (c *Client) Deploy(ctx context.Context, spec *DeployFunctionSpec) (*DeployResult, error) {
+	if spec.Replace {
+		if err := c.Delete(ctx, spec.FunctionName); err != nil && !IsNotFound(err) {
+			return nil, err
+		}
 	}
 
-	if warnInsecureGateway {
-		if (registryAuth != "") && !strings.HasPrefix(gateway, "https") {
-			fmt.Println("WARNING! Communication is not secure, please consider using HTTPS. Letsencrypt.org offers free SSL/TLS certificates.")
+	if spec.Update {
+		result, err := c.Update(ctx, spec)
+		if err == nil || !IsNotFound(err) {
+			return result, err
 		}
 	}
 
-	gateway = strings.TrimRight(gateway, "/")
+	return c.deploy(ctx, http.MethodPost, spec)
+}
+
+// Update performs a rolling update of an existing function, a *StatusError
+// satisfying IsNotFound is returned when the function does not exist.
+func (c *Client) Update(ctx context.Context, spec *DeployFunctionSpec) (*DeployResult, error) {
+	result, err := c.deploy(ctx, http.MethodPut, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	result.RollingUpdate = true
+	return result, nil
+}
+
+func (c *Client) deploy(ctx context.Context, method string, spec *DeployFunctionSpec) (*DeployResult, error) {
+	reqBytes, _ := json.Marshal(makeCreateFunctionRequest(spec))
+
+	req, err := c.newRequest(ctx, method, "/system/functions", bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
 
-	if replace {
-		DeleteFunction(gateway, functionName)
+	if res.Body != nil {
+		defer res.Body.Close()
 	}
 
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return &DeployResult{
+			Status:     res.Status,
+			StatusCode: res.StatusCode,
+			URL:        fmt.Sprintf("%s/function/%s", c.GatewayURL, spec.FunctionName),
+		}, nil
+	default:
+		return nil, statusError(res)
+	}
+}
+
+func makeCreateFunctionRequest(spec *DeployFunctionSpec) requests.CreateFunctionRequest {
+	labels := spec.Labels
+
 	req := requests.CreateFunctionRequest{
-		EnvProcess:   fprocessTemplate,
-		Image:        image,
-		RegistryAuth: registryAuth,
-		Network:      network,
-		Service:      functionName,
-		EnvVars:      envVars,
-		Constraints:  constraints,
-		Secrets:      secrets,
+		EnvProcess:   spec.FProcess,
+		Image:        spec.Image,
+		RegistryAuth: spec.RegistryAuth,
+		Network:      spec.Network,
+		Service:      spec.FunctionName,
+		EnvVars:      spec.EnvVars,
+		Constraints:  spec.Constraints,
+		Secrets:      spec.Secrets,
 		Labels:       &labels,
 	}
 
+	resources := spec.FunctionResourceRequest
+
 	hasLimits := false
 	req.Limits = &requests.FunctionResources{}
-	if functionResourceRequest1.Limits != nil && len(functionResourceRequest1.Limits.Memory) > 0 {
+	if resources.Limits != nil && len(resources.Limits.Memory) > 0 {
 		hasLimits = true
-		req.Limits.Memory = functionResourceRequest1.Limits.Memory
+		req.Limits.Memory = resources.Limits.Memory
 	}
-	if functionResourceRequest1.Limits != nil && len(functionResourceRequest1.Limits.CPU) > 0 {
+	if resources.Limits != nil && len(resources.Limits.CPU) > 0 {
 		hasLimits = true
-		req.Limits.CPU = functionResourceRequest1.Limits.CPU
+		req.Limits.CPU = resources.Limits.CPU
 	}
 	if !hasLimits {
 		req.Limits = nil
@@ -99,71 +151,17 @@ func Deploy(fprocess string, gateway string, functionName string, image string,
 
 	hasRequests := false
 	req.Requests = &requests.FunctionResources{}
-	if functionResourceRequest1.Requests != nil && len(functionResourceRequest1.Requests.Memory) > 0 {
+	if resources.Requests != nil && len(resources.Requests.Memory) > 0 {
 		hasRequests = true
-		req.Requests.Memory = functionResourceRequest1.Requests.Memory
+		req.Requests.Memory = resources.Requests.Memory
 	}
-	if functionResourceRequest1.Requests != nil && len(functionResourceRequest1.Requests.CPU) > 0 {
+	if resources.Requests != nil && len(resources.Requests.CPU) > 0 {
 		hasRequests = true
-		req.Requests.CPU = functionResourceRequest1.Requests.CPU
+		req.Requests.CPU = resources.Requests.CPU
 	}
-
 	if !hasRequests {
 		req.Requests = nil
 	}
 
-	reqBytes, _ := json.Marshal(&req)
-	reader := bytes.NewReader(reqBytes)
-	var request *http.Request
-
-	timeout := 60 * time.Second
-	client := MakeHTTPClient(&timeout)
-
-	method := http.MethodPost
-	// "application/json"
-	if update {
-		method = http.MethodPut
-	}
-
-	var err error
-	request, err = http.NewRequest(method, gateway+"/system/functions", reader)
-	SetAuth(request, gateway)
-	if err != nil {
-		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
-	}
-
-	res, err := client.Do(request)
-	if err != nil {
-		deployOutput += fmt.Sprintln("Is FaaS deployed? Do you need to specify the --gateway flag?")
-		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
-	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
-	}
-
-	switch res.StatusCode {
-	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		deployOutput += fmt.Sprintf("Deployed. %s.\n", res.Status)
-
-		deployedURL := fmt.Sprintf("URL: %s/function/%s", gateway, functionName)
-		deployOutput += fmt.Sprintln(deployedURL)
-	case http.StatusUnauthorized:
-		deployOutput += fmt.Sprintln("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
-		/*
-			case http.StatusNotFound:
-				if replace && !update {
-					deployOutput += fmt.Sprintln("Could not delete-and-replace function because it is not found (404)")
-				}
-		*/
-	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			deployOutput += fmt.Sprintf("Unexpected status: %d, message: %s\n", res.StatusCode, string(bytesOut))
-		}
-	}
-
-	return res.StatusCode, deployOutput
+	return req
 }
@@ -4,9 +4,8 @@
 package proxy
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-
 	"testing"
 
 	"regexp"
@@ -19,7 +18,9 @@ type deployProxyTest struct {
 	mockServerResponses []int
 	replace             bool
 	update              bool
-	expectedOutput      string
+	wantStatusCode      int
+	wantRollingUpdate   bool
+	wantErr             string
 }
 
 func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
@@ -29,28 +30,46 @@ func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
 	)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeployFunction(
-			"fproces",
-			s.URL,
-			"function",
-			"image",
-			"dXNlcjpwYXNzd29yZA==",
-			"language",
-			deployTest.replace,
-			nil,
-			"network",
-			[]string{},
-			deployTest.update,
-			[]string{},
-			map[string]string{},
-			FunctionResourceRequest{},
-		)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	result, err := client.Deploy(context.Background(), &DeployFunctionSpec{
+		FProcess:     "fprocess",
+		FunctionName: "function",
+		Image:        "image",
+		RegistryAuth: "dXNlcjpwYXNzd29yZA==",
+		Language:     "language",
+		Replace:      deployTest.replace,
+		Update:       deployTest.update,
+		Network:      "network",
+		Constraints:  []string{},
+		Secrets:      []string{},
+		Labels:       map[string]string{},
 	})
 
-	r := regexp.MustCompile(deployTest.expectedOutput)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Output not matched: %s", stdout)
+	if len(deployTest.wantErr) > 0 {
+		if err == nil {
+			t.Fatalf("Error was not returned")
+		}
+		r := regexp.MustCompile(deployTest.wantErr)
+		if !r.MatchString(err.Error()) {
+			t.Fatalf("Error not matched: %s", err)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if result.StatusCode != deployTest.wantStatusCode {
+		t.Errorf("StatusCode want: %d, got: %d", deployTest.wantStatusCode, result.StatusCode)
+	}
+
+	if result.RollingUpdate != deployTest.wantRollingUpdate {
+		t.Errorf("RollingUpdate want: %t, got: %t", deployTest.wantRollingUpdate, result.RollingUpdate)
+	}
+
+	if result.URL != s.URL+"/function/function" {
+		t.Errorf("URL want: %s, got: %s", s.URL+"/function/function", result.URL)
 	}
 }
 
@@ -61,21 +80,36 @@ func Test_RunDeployProxyTests(t *testing.T) {
 			mockServerResponses: []int{http.StatusOK, http.StatusOK},
 			replace:             true,
 			update:              false,
-			expectedOutput:      `(?m:Deployed)`,
+			wantStatusCode:      http.StatusOK,
 		},
 		{
 			title:               "404_Deploy",
 			mockServerResponses: []int{http.StatusOK, http.StatusNotFound},
 			replace:             true,
 			update:              false,
-			expectedOutput:      `(?m:Unexpected status: 404)`,
+			wantErr:             `(?m:unexpected status code: 404)`,
 		},
 		{
 			title:               "UpdateFailedDeployed",
-			mockServerResponses: []int{http.StatusNotFound, http.StatusOK},
+			mockServerResponses: []int{http.StatusNotFound, http.StatusAccepted},
 			replace:             false,
 			update:              true,
-			expectedOutput:      `(?m:Deployed)`,
+			wantStatusCode:      http.StatusAccepted,
+		},
+		{
+			title:               "RollingUpdate",
+			mockServerResponses: []int{http.StatusOK},
+			replace:             false,
+			update:              true,
+			wantStatusCode:      http.StatusOK,
+			wantRollingUpdate:   true,
+		},
+		{
+			title:               "Unauthorized",
+			mockServerResponses: []int{http.StatusUnauthorized},
+			replace:             false,
+			update:              true,
+			wantErr:             `(?m:unauthorized access)`,
 		},
 	}
 	for _, tst := range deployProxyTests {
@@ -86,30 +120,18 @@ func Test_RunDeployProxyTests(t *testing.T) {
 }
 
 func Test_DeployFunction_MissingURLPrefix(t *testing.T) {
-	url := "127.0.0.1:8080"
-
-	stdout := test.CaptureStdout(func() {
-		DeployFunction(
-			"fprocess",
-			url,
-			"function",
-			"image",
-			"dXNlcjpwYXNzd29yZA==",
-			"language",
-			false,
-			nil,
-			"network",
-			[]string{},
-			false,
-			[]string{},
-			map[string]string{},
-			FunctionResourceRequest{},
-		)
+	client, _ := NewClient(nil, "127.0.0.1:8080", nil, nil)
+	_, err := client.Deploy(context.Background(), &DeployFunctionSpec{
+		FunctionName: "function",
+		Image:        "image",
 	})
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
 
-	expectedErrMsg := "first path segment in URL cannot contain colon"
-	r := regexp.MustCompile(fmt.Sprintf("(?m:%s)", expectedErrMsg))
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, stdout)
+	expectedErrMsg := "cannot connect to OpenFaaS on URL:"
+	r := regexp.MustCompile(expectedErrMsg)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, err.Error())
 	}
 }
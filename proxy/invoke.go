@@ -5,69 +5,66 @@ package proxy
 
 import (
 	"bytes"
-	"os"
-
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
-	"time"
 )
 
-// InvokeFunction a function
-func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType string, query []string, headers []string, async bool, httpMethod string) (*[]byte, error) {
-	var resBytes []byte
+// InvokeFunctionSpec defines a request to invoke a function
+type InvokeFunctionSpec struct {
+	FunctionName string
+	Body         []byte
+	ContentType  string
 
-	gateway = strings.TrimRight(gateway, "/")
+	// Query and Headers take the form key=value
+	Query   []string
+	Headers []string
 
-	reader := bytes.NewReader(*bytesIn)
+	// Async submits the request to the asynchronous queue
+	Async  bool
+	Method string
+}
 
-	var timeout *time.Duration
-	client := MakeHTTPClient(timeout)
+// Invoke calls a function and returns the body of its response. The body is
+// empty for asynchronous invocations which are only acknowledged by the gateway.
+func (c *Client) Invoke(ctx context.Context, spec *InvokeFunctionSpec) ([]byte, error) {
+	var resBytes []byte
 
-	qs, qsErr := buildQueryString(query)
+	qs, qsErr := buildQueryString(spec.Query)
 	if qsErr != nil {
 		return nil, qsErr
 	}
 
-	headerMap, headerErr := parseHeaders(headers)
+	headerMap, headerErr := parseHeaders(spec.Headers)
 	if headerErr != nil {
 		return nil, headerErr
 	}
 
 	functionEndpoint := "/function/"
-	if async {
+	if spec.Async {
 		functionEndpoint = "/async-function/"
 	}
 
-	httpMethodErr := validateHTTPMethod(httpMethod)
+	httpMethodErr := validateHTTPMethod(spec.Method)
 	if httpMethodErr != nil {
 		return nil, httpMethodErr
 	}
 
-	gatewayURL := gateway + functionEndpoint + name + qs
-
-	req, err := http.NewRequest(httpMethod, gatewayURL, reader)
+	req, err := c.newRequest(ctx, spec.Method, functionEndpoint+spec.FunctionName+qs, bytes.NewReader(spec.Body))
 	if err != nil {
-		fmt.Println()
-		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+		return nil, err
 	}
-
-	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("Content-Type", spec.ContentType)
 	// Add additional headers to request
 	for name, value := range headerMap {
 		req.Header.Add(name, value)
 	}
 
-	SetAuth(req, gateway)
-
-	res, err := client.Do(req)
-
+	res, err := c.do(req)
 	if err != nil {
-		fmt.Println()
-		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+		return nil, err
 	}
 
 	if res.Body != nil {
@@ -76,23 +73,17 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 
 	switch res.StatusCode {
 	case http.StatusAccepted:
-		fmt.Fprintf(os.Stderr, "Function submitted asynchronously.\n")
 	case http.StatusOK:
 		var readErr error
 		resBytes, readErr = ioutil.ReadAll(res.Body)
 		if readErr != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", gateway, readErr)
+			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", c.GatewayURL, readErr)
 		}
-	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
 	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return nil, statusError(res)
 	}
 
-	return &resBytes, nil
+	return resBytes, nil
 }
 
 func buildQueryString(query []string) (string, error) {
@@ -4,6 +4,7 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -19,16 +20,14 @@ func Test_InvokeFunction(t *testing.T) {
 	defer s.Close()
 
 	bytesIn := []byte("test data")
-	_, err := InvokeFunction(
-		s.URL,
-		"function",
-		&bytesIn,
-		"text/plain",
-		[]string{},
-		[]string{},
-		false,
-		http.MethodPost,
-	)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.Invoke(context.Background(), &InvokeFunctionSpec{
+		FunctionName: "function",
+		Body:         bytesIn,
+		ContentType:  "text/plain",
+		Async:        false,
+		Method:       http.MethodPost,
+	})
 
 	if err != nil {
 		t.Fatalf("Error returned: %s", err)
@@ -40,16 +39,14 @@ func Test_InvokeFunction_Async(t *testing.T) {
 	defer s.Close()
 
 	bytesIn := []byte("test data")
-	_, err := InvokeFunction(
-		s.URL,
-		"function",
-		&bytesIn,
-		"text/plain",
-		[]string{},
-		[]string{},
-		true,
-		http.MethodPost,
-	)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.Invoke(context.Background(), &InvokeFunctionSpec{
+		FunctionName: "function",
+		Body:         bytesIn,
+		ContentType:  "text/plain",
+		Async:        true,
+		Method:       http.MethodPost,
+	})
 
 	if err != nil {
 		t.Fatalf("Error returned: %s", err)
@@ -61,16 +58,14 @@ func Test_InvokeFunction_Not2xx(t *testing.T) {
 	defer s.Close()
 
 	bytesIn := []byte("test data")
-	_, err := InvokeFunction(
-		s.URL,
-		"function",
-		&bytesIn,
-		"text/plain",
-		[]string{},
-		[]string{},
-		false,
-		http.MethodPost,
-	)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.Invoke(context.Background(), &InvokeFunctionSpec{
+		FunctionName: "function",
+		Body:         bytesIn,
+		ContentType:  "text/plain",
+		Async:        false,
+		Method:       http.MethodPost,
+	})
 
 	if err == nil {
 		t.Fatalf("Error was not returned")
@@ -85,16 +80,14 @@ func Test_InvokeFunction_Not2xx(t *testing.T) {
 func Test_InvokeFunction_MissingURLPrefix(t *testing.T) {
 
 	bytesIn := []byte("test data")
-	_, err := InvokeFunction(
-		"127.0.0.1:8080",
-		"function",
-		&bytesIn,
-		"text/plain",
-		[]string{},
-		[]string{},
-		false,
-		http.MethodPost,
-	)
+	client, _ := NewClient(nil, "127.0.0.1:8080", nil, nil)
+	_, err := client.Invoke(context.Background(), &InvokeFunctionSpec{
+		FunctionName: "function",
+		Body:         bytesIn,
+		ContentType:  "text/plain",
+		Async:        false,
+		Method:       http.MethodPost,
+	})
 
 	if err == nil {
 		t.Fatalf("Error was not returned")
@@ -4,59 +4,104 @@
 package proxy
 
 import (
-	"encoding/json"
-
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strings"
-	"time"
+	"net/url"
 
 	"github.com/openfaas/faas/gateway/requests"
 )
 
-// ListFunctions list deployed functions
-func ListFunctions(gateway string) ([]requests.Function, error) {
+// FunctionStatus is a deployed function as reported by the gateway. Fields
+// which only some gateway versions report are pointers and nil when missing.
+type FunctionStatus struct {
+	Name              string  `json:"name"`
+	Image             string  `json:"image"`
+	InvocationCount   float64 `json:"invocationCount"`
+	Replicas          uint64  `json:"replicas"`
+	AvailableReplicas uint64  `json:"availableReplicas,omitempty"`
+	EnvProcess        string  `json:"envProcess"`
+
+	Labels      *map[string]string `json:"labels"`
+	Annotations *map[string]string `json:"annotations,omitempty"`
+
+	EnvVars     *map[string]string          `json:"envVars,omitempty"`
+	Secrets     *[]string                   `json:"secrets,omitempty"`
+	Constraints *[]string                   `json:"constraints,omitempty"`
+	Limits      *requests.FunctionResources `json:"limits,omitempty"`
+	Requests    *requests.FunctionResources `json:"requests,omitempty"`
+}
+
+// List returns the functions deployed on the gateway
+func (c *Client) List(ctx context.Context) ([]requests.Function, error) {
 	var results []requests.Function
 
-	gateway = strings.TrimRight(gateway, "/")
+	if err := c.getJSON(ctx, "/system/functions", &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
 
-	timeout := 60 * time.Second
-	client := MakeHTTPClient(&timeout)
+// ListStatus returns the functions deployed on the gateway including the
+// fields which are only reported by some gateway versions
+func (c *Client) ListStatus(ctx context.Context) ([]FunctionStatus, error) {
+	var results []FunctionStatus
 
-	getRequest, err := http.NewRequest(http.MethodGet, gateway+"/system/functions", nil)
-	SetAuth(getRequest, gateway)
-	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+	if err := c.getJSON(ctx, "/system/functions", &results); err != nil {
+		return nil, err
 	}
 
-	res, err := client.Do(getRequest)
+	return results, nil
+}
+
+// GetFunction returns a single deployed function, a *StatusError satisfying
+// IsNotFound is returned when the function does not exist.
+func (c *Client) GetFunction(ctx context.Context, functionName string) (*requests.Function, error) {
+	functions, err := c.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+		return nil, err
+	}
+
+	for _, function := range functions {
+		if function.Name == functionName {
+			return &function, nil
+		}
 	}
 
-	if res.Body != nil {
-		defer res.Body.Close()
+	return nil, &StatusError{
+		StatusCode: http.StatusNotFound,
+		Body:       fmt.Sprintf("function %s not found", functionName),
 	}
+}
 
-	switch res.StatusCode {
-	case http.StatusOK:
+// GetFunctionInfo returns the full record of a deployed function. Gateways
+// without the /system/function/ endpoint are queried through the function list.
+// A *StatusError satisfying IsNotFound is returned when the function does not exist.
+func (c *Client) GetFunctionInfo(ctx context.Context, functionName string) (*FunctionStatus, error) {
+	var result FunctionStatus
 
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", gateway)
-		}
-		jsonErr := json.Unmarshal(bytesOut, &results)
-		if jsonErr != nil {
-			return nil, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", gateway, jsonErr.Error())
-		}
-	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
-	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+	err := c.getJSON(ctx, "/system/function/"+url.PathEscape(functionName), &result)
+	if err == nil {
+		return &result, nil
+	}
+	if !IsNotFound(err) {
+		return nil, err
+	}
+
+	functions, err := c.ListStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, function := range functions {
+		if function.Name == functionName {
+			return &function, nil
 		}
 	}
-	return results, nil
+
+	return nil, &StatusError{
+		StatusCode: http.StatusNotFound,
+		Body:       fmt.Sprintf("function %s not found", functionName),
+	}
 }
@@ -4,6 +4,7 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -23,7 +24,8 @@ func Test_ListFunctions(t *testing.T) {
 	})
 	defer s.Close()
 
-	result, err := ListFunctions(s.URL)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	result, err := client.List(context.Background())
 
 	if err != nil {
 		t.Fatalf("Error returned: %s", err)
@@ -38,7 +40,8 @@ func Test_ListFunctions(t *testing.T) {
 func Test_ListFunctions_Not200(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusBadRequest)
 
-	_, err := ListFunctions(s.URL)
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.List(context.Background())
 
 	if err == nil {
 		t.Fatalf("Error was not returned")
@@ -51,7 +54,8 @@ func Test_ListFunctions_Not200(t *testing.T) {
 }
 
 func Test_ListFunctions_MissingURLPrefix(t *testing.T) {
-	_, err := ListFunctions("127.0.0.1:8080")
+	client, _ := NewClient(nil, "127.0.0.1:8080", nil, nil)
+	_, err := client.List(context.Background())
 
 	if err == nil {
 		t.Fatalf("Error was not returned")
@@ -64,6 +68,126 @@ func Test_ListFunctions_MissingURLPrefix(t *testing.T) {
 	}
 }
 
+func Test_GetFunction(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       expectedListFunctionsResponse,
+		},
+	})
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	result, err := client.GetFunction(context.Background(), "func-test2")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if *result != expectedListFunctionsResponse[1] {
+		t.Fatalf("Expeceted: %#v - Actual: %#v", expectedListFunctionsResponse[1], *result)
+	}
+}
+
+func Test_GetFunction_NotFound(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       expectedListFunctionsResponse,
+		},
+	})
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.GetFunction(context.Background(), "func-missing")
+	if !IsNotFound(err) {
+		t.Fatalf("Want: not found error, got: %v", err)
+	}
+}
+
+func Test_GetFunctionInfo(t *testing.T) {
+	annotations := map[string]string{"topic": "orders"}
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/func-test1",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody: FunctionStatus{
+				Name:              "func-test1",
+				Image:             "image-test1",
+				Replicas:          2,
+				AvailableReplicas: 1,
+				Annotations:       &annotations,
+			},
+		},
+	})
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	result, err := client.GetFunctionInfo(context.Background(), "func-test1")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if result.Image != "image-test1" || result.AvailableReplicas != 1 || (*result.Annotations)["topic"] != "orders" {
+		t.Fatalf("Unexpected function: %#v", *result)
+	}
+}
+
+func Test_GetFunctionInfo_FallsBackToList(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Uri:                "/system/function/func-test2",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       expectedListFunctionsResponse,
+		},
+	})
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	result, err := client.GetFunctionInfo(context.Background(), "func-test2")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if result.Name != "func-test2" || result.Replicas != 2 {
+		t.Fatalf("Unexpected function: %#v", *result)
+	}
+}
+
+func Test_GetFunctionInfo_NotFound(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       expectedListFunctionsResponse,
+		},
+	})
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.GetFunctionInfo(context.Background(), "func-missing")
+	if !IsNotFound(err) {
+		t.Fatalf("Want: not found error, got: %v", err)
+	}
+}
+
+func Test_GetFunctionInfo_Unauthorized(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusUnauthorized)
+	defer s.Close()
+
+	client, _ := NewClient(nil, s.URL, nil, nil)
+	_, err := client.GetFunctionInfo(context.Background(), "func-test1")
+	if err != ErrUnauthorized {
+		t.Fatalf("Want: %s, got: %v", ErrUnauthorized, err)
+	}
+}
+
 var expectedListFunctionsResponse = []requests.Function{
 	{
 		Name:            "func-test1",
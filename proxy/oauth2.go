@@ -0,0 +1,165 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// tokenExpiryDelta refreshes tokens slightly early to allow for clock skew and latency
+const tokenExpiryDelta = 10 * time.Second
+
+// OAuth2ClientCredentialsAuth authenticates requests with access tokens obtained
+// through the OAuth2 client credentials grant. A new token is requested when
+// there is none yet or the current one is about to expire.
+type OAuth2ClientCredentialsAuth struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	Scopes       []string
+
+	// AccessToken and Expiry hold the current token, a zero Expiry never expires
+	AccessToken string
+	Expiry      time.Time
+
+	// OnRefresh is called after a new token was obtained so it can be cached
+	OnRefresh func(accessToken string, expiry time.Time) error
+
+	mutex sync.Mutex
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Set adds a valid access token to the request, requesting a new one if needed
+func (auth *OAuth2ClientCredentialsAuth) Set(req *http.Request) error {
+	auth.mutex.Lock()
+	defer auth.mutex.Unlock()
+
+	if auth.expired() {
+		if err := auth.refresh(req); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+auth.AccessToken)
+	return nil
+}
+
+func (auth *OAuth2ClientCredentialsAuth) expired() bool {
+	if len(auth.AccessToken) == 0 {
+		return true
+	}
+
+	return !auth.Expiry.IsZero() && time.Now().Add(tokenExpiryDelta).After(auth.Expiry)
+}
+
+// refresh requests a new token, sharing the context of the outgoing request
+func (auth *OAuth2ClientCredentialsAuth) refresh(outgoing *http.Request) error {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("invalid OAuth2 token URL: %s", auth.TokenURL)
+	}
+	req = req.WithContext(outgoing.Context())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	timeout := 60 * time.Second
+	client := MakeHTTPClient(&timeout)
+
+	res, err := client.Do(req)
+	if err != nil {
+		if ctxErr := outgoing.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("cannot obtain OAuth2 token from %s: %s", auth.TokenURL, err)
+	}
+	defer res.Body.Close()
+
+	bytesOut, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read OAuth2 token from %s: %s", auth.TokenURL, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot obtain OAuth2 token from %s, status code: %d - %s", auth.TokenURL, res.StatusCode, strings.TrimSpace(string(bytesOut)))
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(bytesOut, &token); err != nil {
+		return fmt.Errorf("cannot parse OAuth2 token from %s: %s", auth.TokenURL, err)
+	}
+
+	if len(token.AccessToken) == 0 {
+		return fmt.Errorf("no access_token returned by %s", auth.TokenURL)
+	}
+
+	if len(token.TokenType) > 0 && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported OAuth2 token type: %s", token.TokenType)
+	}
+
+	auth.AccessToken = token.AccessToken
+	auth.Expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		auth.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	if auth.OnRefresh != nil {
+		if err := auth.OnRefresh(auth.AccessToken, auth.Expiry); err != nil {
+			return fmt.Errorf("cannot cache OAuth2 token: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// newOAuth2Auth creates the provider for a saved auth config, new tokens are
+// cached back into the config file or the credential helper it uses
+func newOAuth2Auth(authConfig *config.AuthConfig) (*OAuth2ClientCredentialsAuth, error) {
+	auth := &OAuth2ClientCredentialsAuth{
+		ClientID:     authConfig.ClientID,
+		ClientSecret: authConfig.ClientSecret,
+		TokenURL:     authConfig.TokenURL,
+		Scopes:       authConfig.Scopes,
+		AccessToken:  authConfig.Token,
+	}
+
+	if len(authConfig.TokenExpiry) > 0 {
+		expiry, err := time.Parse(time.RFC3339, authConfig.TokenExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token_expiry for gateway %s: %s", authConfig.Gateway, err)
+		}
+		auth.Expiry = expiry
+	}
+
+	auth.OnRefresh = func(accessToken string, expiry time.Time) error {
+		cached := *authConfig
+		cached.Token = accessToken
+		cached.TokenExpiry = ""
+		if !expiry.IsZero() {
+			cached.TokenExpiry = expiry.Format(time.RFC3339)
+		}
+		return config.SaveAuthConfig(cached)
+	}
+
+	return auth, nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+func Test_OAuth2ClientCredentialsAuth_RefreshesExpiredToken(t *testing.T) {
+	tokenRequests := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "faas-cli" || clientSecret != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "openfaas admin" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, tokenRequests)
+	}))
+	defer tokenServer.Close()
+
+	gatewayServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer gatewayServer.Close()
+
+	var cachedToken string
+	auth := &OAuth2ClientCredentialsAuth{
+		ClientID:     "faas-cli",
+		ClientSecret: "s3cr3t",
+		TokenURL:     tokenServer.URL,
+		Scopes:       []string{"openfaas", "admin"},
+		AccessToken:  "expired-token",
+		Expiry:       time.Now().Add(-time.Minute),
+		OnRefresh: func(accessToken string, expiry time.Time) error {
+			cachedToken = accessToken
+			return nil
+		},
+	}
+
+	client, _ := NewClient(auth, gatewayServer.URL, nil, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := client.List(context.Background()); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("want 1 token request, got %d", tokenRequests)
+	}
+
+	if cachedToken != "token-1" {
+		t.Errorf("want cached token %q, got %q", "token-1", cachedToken)
+	}
+}
+
+func Test_OAuth2ClientCredentialsAuth_TokenEndpointError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+
+	auth := &OAuth2ClientCredentialsAuth{
+		ClientID:     "faas-cli",
+		ClientSecret: "wrong",
+		TokenURL:     tokenServer.URL,
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://openfaas.test/system/functions", nil)
+	if err := auth.Set(req); err == nil {
+		t.Fatalf("Error was not returned")
+	}
+}
+
+func Test_newOAuth2Auth_CachesTokenWithCredsStore(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-oauth2-test")
+	config.DefaultFile = "oauth2-creds-store.yml"
+	defer os.RemoveAll(config.DefaultDir)
+
+	if err := ioutil.WriteFile(filepath.Join(config.DefaultDir, config.DefaultFile), []byte("credsStore: faas-test\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	auth, err := newOAuth2Auth(&config.AuthConfig{Gateway: "http://openfaas.test", Auth: config.OAuth2AuthType, ClientID: "faas-cli"})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if auth.OnRefresh == nil {
+		t.Fatalf("want refreshed tokens to be cached when a credsStore is configured")
+	}
+}
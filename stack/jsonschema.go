@@ -0,0 +1,178 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// schemaDescriptions documents the fields of the stack file in the JSON
+// Schema, keyed by type and YAML name. Every field needs a description.
+var schemaDescriptions = map[string]string{
+	"Services":           "An OpenFaaS stack file describing a set of functions and the gateway they are deployed to",
+	"Services.provider":  "The gateway the functions are deployed to",
+	"Services.functions": "The functions in the stack, keyed by function name",
+	"Services.templates": "Repositories of language templates pulled into ./template, the commits pulled are recorded in template.lock",
+
+	"Provider":         "The OpenFaaS gateway",
+	"Provider.name":    "The provider, always faas or openfaas",
+	"Provider.gateway": "URL of the gateway starting with http(s)://",
+	"Provider.network": "Network the functions are attached to",
+
+	"Function":                  "A function to build, push and deploy",
+	"Function.lang":             "Language template from ./template used to build the function, or dockerfile",
+	"Function.handler":          "Folder with the source code of the function",
+	"Function.image":            "Docker image of the function including a registry or username prefix",
+	"Function.registry_auth":    "Base64 encoded credentials the gateway uses to pull the image",
+	"Function.fprocess":         "Process run by the watchdog for each request",
+	"Function.environment":      "Environment variables set for the function",
+	"Function.secrets":          "Secrets made available to the function",
+	"Function.skip_build":       "Skip the build and push of a pre-built image",
+	"Function.constraints":      "Placement constraints for the orchestrator",
+	"Function.environment_file": "YAML files with an environment map, later files override earlier ones",
+	"Function.labels":           "Labels set on the function",
+	"Function.limits":           "The most memory and CPU the function can use",
+	"Function.requests":         "The memory and CPU reserved for the function",
+	"Function.build_options":    "Build options from the language template which add native packages",
+	"Function.build_args":       "Build args passed to the Docker build, --build-arg flags take precedence",
+	"Function.build_target":     "Stage of a multi-stage Dockerfile to build",
+	"Function.platforms":        "Platforms such as linux/amd64 and linux/arm64 to build a multi-arch image for",
+
+	"TemplateSource":        "A git repository with a template folder of language templates",
+	"TemplateSource.source": "URL or path of the git repository",
+	"TemplateSource.ref":    "Tag, branch or commit to pull, the default branch when empty",
+
+	"FunctionResources":        "Memory and CPU for a function",
+	"FunctionResources.memory": "Memory quantity such as 128Mi or 1Gi",
+	"FunctionResources.cpu":    "CPU quantity such as 100m or 0.5",
+}
+
+// schemaOverrides adds constraints which cannot be found from the Go types
+var schemaOverrides = map[string]map[string]interface{}{
+	"Services.functions": {
+		"propertyNames": map[string]interface{}{"pattern": "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"},
+	},
+	"Provider.name": {
+		"enum": []string{providerName, providerNameLong},
+	},
+	"FunctionResources.memory": {
+		"type": []string{"string", "number"},
+	},
+	"FunctionResources.cpu": {
+		"type": []string{"string", "number"},
+	},
+	"Function.platforms": {
+		"items": map[string]interface{}{"type": "string", "pattern": "^[a-z0-9]+/[a-z0-9]+(/[a-z0-9]+)?$"},
+	},
+}
+
+// JSONSchema returns a JSON Schema for the stack file format generated from
+// the yaml tags of Services and the types it uses, so editors can complete
+// and validate stack files
+func JSONSchema() ([]byte, error) {
+	definitions := map[string]interface{}{}
+	if _, err := typeSchema(reflect.TypeOf(Services{}), definitions); err != nil {
+		return nil, err
+	}
+
+	schema := definitions["Services"].(map[string]interface{})
+	delete(definitions, "Services")
+
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "OpenFaaS stack file"
+	schema["definitions"] = definitions
+
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func typeSchema(t reflect.Type, definitions map[string]interface{}) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), definitions)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Slice:
+		items, err := typeSchema(t.Elem(), definitions)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := typeSchema(t.Elem(), definitions)
+		if err != nil {
+			return nil, err
+		}
+		// YAML scalars such as true or 10 are read into string values
+		if t.Elem().Kind() == reflect.String {
+			values = map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if _, ok := definitions[t.Name()]; !ok {
+			if err := structSchema(t, definitions); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}, nil
+	default:
+		return nil, fmt.Errorf("no JSON Schema type for %s", t)
+	}
+}
+
+func structSchema(t reflect.Type, definitions map[string]interface{}) error {
+	description, ok := schemaDescriptions[t.Name()]
+	if !ok {
+		return fmt.Errorf("no description for %s in the stack file schema", t.Name())
+	}
+
+	properties := map[string]interface{}{}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"description":          description,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	// Recursive types refer to the definition while it is being built
+	definitions[t.Name()] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+
+		key := t.Name() + "." + name
+		description, ok := schemaDescriptions[key]
+		if !ok {
+			return fmt.Errorf("no description for %s in the stack file schema", key)
+		}
+
+		property, err := typeSchema(t.Field(i).Type, definitions)
+		if err != nil {
+			return err
+		}
+
+		// A description next to a $ref is ignored, so wrap references
+		if _, isRef := property["$ref"]; isRef {
+			property = map[string]interface{}{"allOf": []interface{}{property}}
+		}
+		property["description"] = description
+		for k, v := range schemaOverrides[key] {
+			property[k] = v
+		}
+
+		properties[name] = property
+	}
+
+	return nil
+}
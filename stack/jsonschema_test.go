@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Update the golden files in testdata")
+
+const schemaGoldenFile = "testdata/stack.schema.json"
+
+// Test_JSONSchema_Golden keeps the published schema in sync with the structs,
+// run "go test ./stack/ -update" after changing them
+func Test_JSONSchema_Golden(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if *updateGolden {
+		if err := ioutil.WriteFile(schemaGoldenFile, schema, 0644); err != nil {
+			t.Fatalf("Error returned: %s", err)
+		}
+	}
+
+	golden, err := ioutil.ReadFile(schemaGoldenFile)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if string(schema) != string(golden) {
+		t.Fatalf("%s is out of date, run \"go test ./stack/ -update\" and commit the result", schemaGoldenFile)
+	}
+}
+
+func Test_JSONSchema_Functions(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	var document struct {
+		Definitions map[string]struct {
+			Properties           map[string]map[string]interface{} `json:"properties"`
+			AdditionalProperties bool                              `json:"additionalProperties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(schema, &document); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	function, ok := document.Definitions["Function"]
+	if !ok {
+		t.Fatalf("want a Function definition")
+	}
+	if function.AdditionalProperties {
+		t.Fatalf("want unknown function fields to be rejected")
+	}
+	for _, name := range []string{"lang", "handler", "image", "environment", "limits"} {
+		if _, ok := function.Properties[name]; !ok {
+			t.Errorf("want property %s in the Function definition", name)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// UnknownField is a key in a stack file which does not match any field, it
+// is otherwise dropped silently when the file is parsed
+type UnknownField struct {
+	// Path is the dotted path of the key such as "functions.figlet.enviroment"
+	Path string
+	Key  string
+
+	// Suggestion is the closest known field when the key looks like a typo
+	Suggestion string
+}
+
+// FindUnknownFields returns the keys in data which do not match a field of
+// Services, maps such as environment and labels accept any key
+func FindUnknownFields(data []byte) ([]UnknownField, error) {
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var unknown []UnknownField
+	findUnknownFields(document, reflect.TypeOf(Services{}), nil, &unknown)
+	return unknown, nil
+}
+
+func findUnknownFields(value interface{}, valueType reflect.Type, path []string, unknown *[]UnknownField) {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	if items, ok := value.([]interface{}); ok && valueType.Kind() == reflect.Slice {
+		for i, item := range items {
+			findUnknownFields(item, valueType.Elem(), append(append([]string{}, path...), fmt.Sprint(i)), unknown)
+		}
+		return
+	}
+
+	document, ok := value.(yaml.MapSlice)
+	if !ok {
+		return
+	}
+
+	switch valueType.Kind() {
+	case reflect.Map:
+		for _, item := range document {
+			keyPath := append(append([]string{}, path...), fmt.Sprint(item.Key))
+			findUnknownFields(item.Value, valueType.Elem(), keyPath, unknown)
+		}
+	case reflect.Struct:
+		fields := map[string]reflect.Type{}
+		var names []string
+		for i := 0; i < valueType.NumField(); i++ {
+			name := strings.Split(valueType.Field(i).Tag.Get("yaml"), ",")[0]
+			if len(name) > 0 && name != "-" {
+				fields[name] = valueType.Field(i).Type
+				names = append(names, name)
+			}
+		}
+
+		for _, item := range document {
+			key := fmt.Sprint(item.Key)
+			fieldPath := append(append([]string{}, path...), key)
+
+			fieldType, ok := fields[key]
+			if !ok {
+				*unknown = append(*unknown, UnknownField{
+					Path:       strings.Join(fieldPath, "."),
+					Key:        key,
+					Suggestion: closestName(key, names),
+				})
+				continue
+			}
+
+			findUnknownFields(item.Value, fieldType, fieldPath, unknown)
+		}
+	}
+}
+
+// closestName returns the name within two edits of key, if there is one
+func closestName(key string, names []string) string {
+	sort.Strings(names)
+
+	closest, best := "", 3
+	for _, name := range names {
+		if distance := editDistance(strings.ToLower(key), name); distance < best {
+			closest, best = name, distance
+		}
+	}
+	return closest
+}
+
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// KeyLines returns the line of each key in a block style YAML document by
+// its dotted path, such as "functions.figlet.image". List entries are
+// numbered from 0 as in the paths of FindUnknownFields, such as
+// "templates.0.source". Keys in flow style are not found.
+func KeyLines(data []byte) map[string]int {
+	type key struct {
+		indent int
+		name   string
+		item   bool
+	}
+
+	lines := map[string]int{}
+	items := map[string]int{}
+	var parents []key
+
+	keyPath := func() string {
+		var path []string
+		for _, parent := range parents {
+			path = append(path, parent.name)
+		}
+		return strings.Join(path, ".")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		content := strings.TrimLeft(text, " ")
+		indent := len(text) - len(content)
+		if len(strings.TrimSpace(content)) == 0 || strings.HasPrefix(content, "#") {
+			continue
+		}
+
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			// a list may sit at the same indent as its key, so only the
+			// previous entry of the list is closed at this indent
+			for len(parents) > 0 && (parents[len(parents)-1].indent > indent ||
+				parents[len(parents)-1].indent == indent && parents[len(parents)-1].item) {
+				parents = parents[:len(parents)-1]
+			}
+
+			list := keyPath()
+			parents = append(parents, key{indent: indent, name: fmt.Sprint(items[list]), item: true})
+			items[list]++
+			if _, ok := lines[keyPath()]; !ok {
+				lines[keyPath()] = line
+			}
+
+			// the entry may start with its first key, as in "- source: ..."
+			entry := strings.TrimLeft(content[1:], " ")
+			indent += len(content) - len(entry)
+			content = entry
+			if len(content) == 0 || strings.HasPrefix(content, "#") || strings.HasPrefix(content, "-") ||
+				strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[") {
+				continue
+			}
+		}
+
+		colon := strings.Index(content, ": ")
+		if colon == -1 {
+			if !strings.HasSuffix(strings.TrimSpace(content), ":") {
+				continue
+			}
+			colon = len(strings.TrimSpace(content)) - 1
+		}
+		name := strings.Trim(content[:colon], `"'`)
+
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		parents = append(parents, key{indent: indent, name: name})
+
+		if _, ok := lines[keyPath()]; !ok {
+			lines[keyPath()] = line
+		}
+	}
+
+	return lines
+}
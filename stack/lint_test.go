@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+const lintData = `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+
+functions:
+  # a comment
+  figlet:
+    image: functions/figlet:0.1
+    limit:
+      memory: 40m
+    environment:
+      any_key: value
+    secrets:
+      - api-key
+    labels: {team: fonts}
+
+templates:
+  - source: https://github.com/openfaas/templates.git
+    rev: 1.2.0
+`
+
+func Test_FindUnknownFields(t *testing.T) {
+	unknown, err := FindUnknownFields([]byte(lintData))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	want := []UnknownField{
+		{Path: "functions.figlet.limit", Key: "limit", Suggestion: "limits"},
+		{Path: "templates.0.rev", Key: "rev", Suggestion: "ref"},
+	}
+	if !reflect.DeepEqual(unknown, want) {
+		t.Fatalf("want %+v, got %+v", want, unknown)
+	}
+}
+
+func Test_KeyLines(t *testing.T) {
+	lines := KeyLines([]byte(lintData))
+
+	want := map[string]int{
+		"provider":                      1,
+		"provider.gateway":              3,
+		"functions.figlet":              7,
+		"functions.figlet.limit":        9,
+		"functions.figlet.secrets":      13,
+		"functions.figlet.labels":       15,
+		"functions.figlet.image":        8,
+		"functions.figlet.limit.memory": 10,
+		"templates.0":                   18,
+		"templates.0.source":            18,
+		"templates.0.rev":               19,
+	}
+	for path, line := range want {
+		if lines[path] != line {
+			t.Errorf("want %s on line %d, got %d", path, line, lines[path])
+		}
+	}
+}
+
+func Test_KeyLines_UnknownFieldInListEntry(t *testing.T) {
+	data := `functions:
+  figlet:
+    image: functions/figlet:0.1
+    secrets:
+    - api-key
+    - db-password
+templates:
+- source: https://github.com/openfaas/templates.git
+  ref: 1.2.0
+-
+  source: https://github.com/openfaas-incubator/node10-express-template.git
+  refs: master
+`
+
+	unknown, err := FindUnknownFields([]byte(data))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if len(unknown) != 1 || unknown[0].Path != "templates.1.refs" {
+		t.Fatalf("want the unknown field templates.1.refs, got %+v", unknown)
+	}
+
+	lines := KeyLines([]byte(data))
+	want := map[string]int{
+		"functions.figlet.secrets.1": 6,
+		"templates.0.source":         8,
+		"templates.0.ref":            9,
+		"templates.1.source":         11,
+		"templates.1.refs":           12,
+	}
+	for path, line := range want {
+		if lines[path] != line {
+			t.Errorf("want %s on line %d, got %d", path, line, lines[path])
+		}
+	}
+}
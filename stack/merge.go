@@ -0,0 +1,146 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// appendLists are the function fields where an overlay adds to the list from
+// the files before it, any other list in an overlay replaces the earlier one
+var appendLists = map[string]bool{
+	"secrets":          true,
+	"environment_file": true,
+}
+
+// MergeServices applies an overlay YAML document on top of services.
+//
+// Only the fields written in the overlay are changed:
+//   - scalars such as image or gateway replace the earlier value
+//   - maps such as environment and labels are merged key by key
+//   - secrets and environment_file are appended to, skipping duplicates
+//   - constraints, build_options, templates and other lists are replaced
+//   - a field set to null removes the earlier value
+//   - functions which are only in the overlay are added as they are
+func MergeServices(services *Services, overlayData []byte) error {
+	var overlay Services
+	if err := yaml.Unmarshal(overlayData, &overlay); err != nil {
+		return err
+	}
+
+	// The overlay is read a second time to know which fields were written
+	var written yaml.MapSlice
+	if err := yaml.Unmarshal(overlayData, &written); err != nil {
+		return err
+	}
+
+	if provider, ok := lookupKey(written, "provider"); ok {
+		mergeFields(reflect.ValueOf(&services.Provider).Elem(), reflect.ValueOf(overlay.Provider), toMapSlice(provider))
+	}
+
+	if _, ok := lookupKey(written, "templates"); ok {
+		services.Templates = overlay.Templates
+	}
+
+	functions, _ := lookupKey(written, "functions")
+	for _, item := range toMapSlice(functions) {
+		name := fmt.Sprint(item.Key)
+		if services.Functions == nil {
+			services.Functions = map[string]Function{}
+		}
+
+		function, ok := services.Functions[name]
+		if !ok {
+			services.Functions[name] = overlay.Functions[name]
+			continue
+		}
+
+		mergeFields(reflect.ValueOf(&function).Elem(), reflect.ValueOf(overlay.Functions[name]), toMapSlice(item.Value))
+		services.Functions[name] = function
+	}
+
+	return nil
+}
+
+// mergeFields copies the fields of src which are written in the overlay into dst
+func mergeFields(dst reflect.Value, src reflect.Value, written yaml.MapSlice) {
+	for i := 0; i < dst.NumField(); i++ {
+		name := strings.Split(dst.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+
+		value, ok := lookupKey(written, name)
+		if !ok {
+			continue
+		}
+
+		mergeValue(dst.Field(i), src.Field(i), value, appendLists[name])
+	}
+}
+
+func mergeValue(dst reflect.Value, src reflect.Value, written interface{}, appendList bool) {
+	if written == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() || dst.IsNil() {
+			dst.Set(src)
+			return
+		}
+		mergeValue(dst.Elem(), src.Elem(), written, appendList)
+	case reflect.Struct:
+		mergeFields(dst, src, toMapSlice(written))
+	case reflect.Map:
+		if src.IsNil() || dst.IsNil() {
+			dst.Set(src)
+			return
+		}
+		for _, key := range src.MapKeys() {
+			dst.SetMapIndex(key, src.MapIndex(key))
+		}
+	case reflect.Slice:
+		if !appendList || src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		for i := 0; i < src.Len(); i++ {
+			if !containsValue(dst, src.Index(i)) {
+				dst.Set(reflect.Append(dst, src.Index(i)))
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+func containsValue(list reflect.Value, item reflect.Value) bool {
+	for i := 0; i < list.Len(); i++ {
+		if reflect.DeepEqual(list.Index(i).Interface(), item.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupKey(document yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range document {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func toMapSlice(value interface{}) yaml.MapSlice {
+	document, _ := value.(yaml.MapSlice)
+	return document
+}
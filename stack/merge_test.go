@@ -0,0 +1,131 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const mergeBase = `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+  network: func_functions
+
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: functions/figlet:latest
+    skip_build: true
+    environment:
+      write_debug: true
+      read_timeout: 10s
+    labels:
+      team: fonts
+    secrets:
+      - api-key
+    constraints:
+      - node.role == worker
+    limits:
+      memory: 40m
+      cpu: 100m
+
+templates:
+  - source: https://github.com/openfaas/templates.git
+    ref: 1.2.0
+`
+
+const mergeOverlay = `provider:
+  gateway: https://gateway.example.com
+
+functions:
+  figlet:
+    image: registry.example.com/figlet:0.2.0
+    skip_build: false
+    environment:
+      write_debug: false
+    labels: ~
+    secrets:
+      - api-key
+      - registry-key
+    constraints:
+      - node.labels.tier == prod
+    limits:
+      memory: 128m
+  markdown:
+    image: functions/markdown-render:latest
+
+templates:
+  - source: https://github.com/openfaas-incubator/golang-http-template.git
+`
+
+func Test_MergeServices(t *testing.T) {
+	services, err := ParseYAMLData([]byte(mergeBase), "", "")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if err := MergeServices(services, []byte(mergeOverlay)); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	wantProvider := Provider{Name: "faas", GatewayURL: "https://gateway.example.com", Network: "func_functions"}
+	if services.Provider != wantProvider {
+		t.Fatalf("want provider %+v, got %+v", wantProvider, services.Provider)
+	}
+
+	constraints := []string{"node.labels.tier == prod"}
+	wantFiglet := Function{
+		Language:    "dockerfile",
+		Handler:     "./figlet",
+		Image:       "registry.example.com/figlet:0.2.0",
+		SkipBuild:   false,
+		Environment: map[string]string{"write_debug": "false", "read_timeout": "10s"},
+		Secrets:     []string{"api-key", "registry-key"},
+		Constraints: &constraints,
+		Limits:      &FunctionResources{Memory: "128m", CPU: "100m"},
+	}
+	if got := services.Functions["figlet"]; !reflect.DeepEqual(got, wantFiglet) {
+		t.Fatalf("want figlet %+v, got %+v", wantFiglet, got)
+	}
+
+	if image := services.Functions["markdown"].Image; image != "functions/markdown-render:latest" {
+		t.Fatalf("want the function from the overlay to be added, got image %q", image)
+	}
+
+	wantTemplates := []TemplateSource{{Source: "https://github.com/openfaas-incubator/golang-http-template.git"}}
+	if !reflect.DeepEqual(services.Templates, wantTemplates) {
+		t.Fatalf("want the templates replaced by the overlay, got %+v", services.Templates)
+	}
+}
+
+func Test_ParseYAMLFilesWithLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-merge")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.yml")
+	overlay := filepath.Join(dir, "prod.yml")
+	if err := ioutil.WriteFile(base, []byte(mergeBase), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if err := ioutil.WriteFile(overlay, []byte(mergeOverlay), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	services, err := ParseYAMLFilesWithLookup([]string{base, overlay}, "", "mark*", NewLookup(nil))
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if len(services.Functions) != 1 || services.Functions["markdown"].Image == "" {
+		t.Fatalf("want the filter applied after merging, got %+v", services.Functions)
+	}
+}
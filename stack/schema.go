@@ -7,17 +7,17 @@ package stack
 type Provider struct {
 	Name       string `yaml:"name"`
 	GatewayURL string `yaml:"gateway"`
-	Network    string `yaml:"network"`
+	Network    string `yaml:"network,omitempty"`
 }
 
 // Function as deployed or built on FaaS
 type Function struct {
 	// Name of deployed function
 	Name     string `yaml:"-"`
-	Language string `yaml:"lang"`
+	Language string `yaml:"lang,omitempty"`
 
 	// Handler Local folder to use for function
-	Handler string `yaml:"handler"`
+	Handler string `yaml:"handler,omitempty"`
 
 	// Image Docker image name
 	Image string `yaml:"image"`
@@ -25,37 +25,46 @@ type Function struct {
 	// Docker registry Authorization
 	RegistryAuth string `yaml:"registry_auth,omitempty"`
 
-	FProcess string `yaml:"fprocess"`
+	FProcess string `yaml:"fprocess,omitempty"`
 
-	Environment map[string]string `yaml:"environment"`
+	Environment map[string]string `yaml:"environment,omitempty"`
 
 	// Secrets list of secrets to be made available to function
-	Secrets []string `yaml:"secrets"`
+	Secrets []string `yaml:"secrets,omitempty"`
 
-	SkipBuild bool `yaml:"skip_build"`
+	SkipBuild bool `yaml:"skip_build,omitempty"`
 
-	Constraints *[]string `yaml:"constraints"`
+	Constraints *[]string `yaml:"constraints,omitempty"`
 
 	// EnvironmentFile is a list of files to import and override environmental variables.
 	// These are overriden in order.
-	EnvironmentFile []string `yaml:"environment_file"`
+	EnvironmentFile []string `yaml:"environment_file,omitempty"`
 
-	Labels *map[string]string `yaml:"labels"`
+	Labels *map[string]string `yaml:"labels,omitempty"`
 
 	// Limits for function
-	Limits *FunctionResources `yaml:"limits"`
+	Limits *FunctionResources `yaml:"limits,omitempty"`
 
 	// Requests of resources requested by function
-	Requests *FunctionResources `yaml:"requests"`
+	Requests *FunctionResources `yaml:"requests,omitempty"`
 
 	// BuildOptions to determine native packages
-	BuildOptions []string `yaml:"build_options"`
+	BuildOptions []string `yaml:"build_options,omitempty"`
+
+	// BuildArgs are passed to the build, --build-arg flags take precedence
+	BuildArgs map[string]string `yaml:"build_args,omitempty"`
+
+	// BuildTarget is the stage of a multi-stage Dockerfile to build
+	BuildTarget string `yaml:"build_target,omitempty"`
+
+	// Platforms to build and push a multi-arch image for, such as linux/arm64
+	Platforms []string `yaml:"platforms,omitempty"`
 }
 
 // FunctionResources Memory and CPU
 type FunctionResources struct {
-	Memory string `yaml:"memory"`
-	CPU    string `yaml:"cpu"`
+	Memory string `yaml:"memory,omitempty"`
+	CPU    string `yaml:"cpu,omitempty"`
 }
 
 // EnvironmentFile represents external file for environment data
@@ -63,10 +72,22 @@ type EnvironmentFile struct {
 	Environment map[string]string `yaml:"environment"`
 }
 
+// TemplateSource is a git repository of language templates
+type TemplateSource struct {
+	// Source is the URL or path of the repository
+	Source string `yaml:"source"`
+
+	// Ref is the tag, branch or commit to pull, the default branch when empty
+	Ref string `yaml:"ref,omitempty"`
+}
+
 // Services root level YAML file to define FaaS function-set
 type Services struct {
-	Functions map[string]Function `yaml:"functions,omitempty"`
 	Provider  Provider            `yaml:"provider,omitempty"`
+	Functions map[string]Function `yaml:"functions,omitempty"`
+
+	// Templates are pulled into ./template by template pull and build
+	Templates []TemplateSource `yaml:"templates,omitempty"`
 }
 
 // LanguageTemplate read from template.yml within root of a language template folder
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
 	"time"
 
@@ -19,38 +20,93 @@ import (
 const providerName = "faas"
 const providerNameLong = "openfaas"
 
-// ParseYAMLData parse YAML file into a stack of "services".
+// ParseYAMLFile parse YAML file into a stack of "services", variables are
+// substituted from the process environment.
 func ParseYAMLFile(yamlFile, regex, filter string) (*Services, error) {
-	var err error
-	var fileData []byte
-	urlParsed, err := url.Parse(yamlFile)
-	if err == nil && len(urlParsed.Scheme) > 0 {
-		fmt.Println("Parsed: " + urlParsed.String())
-		fileData, err = fetchYAML(urlParsed)
+	return ParseYAMLFileWithLookup(yamlFile, regex, filter, os.LookupEnv)
+}
+
+// ParseYAMLFileWithLookup parse YAML file into a stack of "services" after
+// substituting ${VAR} references with the values from lookup.
+func ParseYAMLFileWithLookup(yamlFile, regex, filter string, lookup LookupFunc) (*Services, error) {
+	return ParseYAMLFilesWithLookup([]string{yamlFile}, regex, filter, lookup)
+}
+
+// ParseYAMLFilesWithLookup parse a base YAML file and zero to many overlays
+// into a single stack of "services", see MergeServices for how each overlay
+// is applied on top of the files before it.
+func ParseYAMLFilesWithLookup(yamlFiles []string, regex, filter string, lookup LookupFunc) (*Services, error) {
+	var services Services
+	for i, yamlFile := range yamlFiles {
+		fileData, err := ReadYAMLFile(yamlFile, lookup)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		fileData, err = ioutil.ReadFile(yamlFile)
-		if err != nil {
-			return nil, err
+
+		if i == 0 {
+			if err := unmarshalServices(fileData, &services); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := MergeServices(&services, fileData); err != nil {
+			return nil, fmt.Errorf("cannot merge %s: %s", yamlFile, err)
 		}
 	}
-	return ParseYAMLData(fileData, regex, filter)
+
+	return filterServices(&services, regex, filter)
+}
+
+// ReadYAMLFile reads a YAML file from disk or from a remote location and
+// substitutes ${VAR} references with the values from lookup
+func ReadYAMLFile(yamlFile string, lookup LookupFunc) ([]byte, error) {
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return SubstituteVariables(fileData, yamlFile, lookup)
+}
+
+// readYAML reads a YAML file from disk or from a remote location
+func readYAML(yamlFile string) ([]byte, error) {
+	urlParsed, err := url.Parse(yamlFile)
+	if err == nil && len(urlParsed.Scheme) > 0 {
+		// Written to stderr so the output of "stack render" is only YAML
+		fmt.Fprintln(os.Stderr, "Parsed: "+urlParsed.String())
+		return fetchYAML(urlParsed)
+	}
+
+	return ioutil.ReadFile(yamlFile)
 }
 
 // ParseYAMLData parse YAML data into a stack of "services".
 func ParseYAMLData(fileData []byte, regex string, filter string) (*Services, error) {
 	var services Services
-	regexExists := len(regex) > 0
-	filterExists := len(filter) > 0
+	if err := unmarshalServices(fileData, &services); err != nil {
+		return nil, err
+	}
+
+	return filterServices(&services, regex, filter)
+}
 
-	err := yaml.Unmarshal(fileData, &services)
+func unmarshalServices(fileData []byte, services *Services) error {
+	err := yaml.Unmarshal(fileData, services)
 	if err != nil {
 		fmt.Printf("Error with YAML file\n")
-		return nil, err
+		return err
 	}
 
+	return nil
+}
+
+// filterServices validates the provider and removes the functions which do
+// not match the regex or filter
+func filterServices(services *Services, regex string, filter string) (*Services, error) {
+	regexExists := len(regex) > 0
+	filterExists := len(filter) > 0
+
 	for _, f := range services.Functions {
 		if f.Language == "Dockerfile" {
 			f.Language = "dockerfile"
@@ -91,7 +147,7 @@ func ParseYAMLData(fileData []byte, regex string, filter string) (*Services, err
 
 	}
 
-	return &services, nil
+	return services, nil
 }
 
 func makeHTTPClient(timeout *time.Duration) http.Client {
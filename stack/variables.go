@@ -0,0 +1,169 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// LookupFunc returns the value of a variable referenced from a stack file
+type LookupFunc func(name string) (string, bool)
+
+var variableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// NewLookup returns a LookupFunc which prefers the process environment over
+// values, so a variable can always be overridden when running the CLI
+func NewLookup(values map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+// SubstituteVariables expands ${VAR} and ${VAR:-default} references in data
+// before it is unmarshalled. "$${" is written out as a literal "${". Every
+// undefined variable without a default is reported with its line in source.
+// Comments are copied as they are, so a commented out line may reference
+// variables which are not set.
+func SubstituteVariables(data []byte, source string, lookup LookupFunc) ([]byte, error) {
+	var out bytes.Buffer
+	var errs []string
+	line := 1
+	comment := commentIndex(data)
+
+	for i := 0; i < len(data); i++ {
+		switch {
+		case i == comment:
+			end := bytes.IndexByte(data[i:], '\n')
+			if end == -1 {
+				end = len(data) - i
+			}
+			out.Write(data[i : i+end])
+			i += end - 1
+		case data[i] == '\n':
+			line++
+			out.WriteByte(data[i])
+			comment = commentIndex(data[i+1:])
+			if comment > -1 {
+				comment += i + 1
+			}
+		case bytes.HasPrefix(data[i:], []byte("$${")):
+			out.WriteString("${")
+			i += 2
+		case bytes.HasPrefix(data[i:], []byte("${")):
+			end := bytes.IndexAny(data[i:], "}\n")
+			if end == -1 || data[i+end] != '}' {
+				errs = append(errs, fmt.Sprintf("%s:%d: unterminated variable reference, missing '}'", source, line))
+				out.WriteByte(data[i])
+				continue
+			}
+
+			reference := string(data[i+2 : i+end])
+			name, defaultValue, hasDefault := reference, "", false
+			if index := strings.Index(reference, ":-"); index > -1 {
+				name, defaultValue, hasDefault = reference[:index], reference[index+2:], true
+			}
+
+			switch value, ok := lookup(name); {
+			case !variableName.MatchString(name):
+				errs = append(errs, fmt.Sprintf("%s:%d: invalid variable reference ${%s}", source, line, reference))
+			case ok:
+				out.WriteString(value)
+			case hasDefault:
+				out.WriteString(defaultValue)
+			default:
+				errs = append(errs, fmt.Sprintf("%s:%d: variable %s is not set and has no default, use ${%s:-default} to give one", source, line, name, name))
+			}
+
+			i += end
+		default:
+			out.WriteByte(data[i])
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("cannot substitute variables in stack file:\n%s", strings.Join(errs, "\n"))
+	}
+
+	return out.Bytes(), nil
+}
+
+// commentIndex returns where the comment on the first line of data starts,
+// or -1. A YAML comment is a # at the start of a line or after whitespace
+// which is not inside a quoted scalar.
+func commentIndex(data []byte) int {
+	var quote byte
+	for i := 0; i < len(data) && data[i] != '\n'; i++ {
+		c := data[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || data[i-1] == ' ' || data[i-1] == '\t'):
+			return i
+		case (c == '"' || c == '\'') && startsScalar(data[:i]):
+			quote = c
+		}
+	}
+	return -1
+}
+
+// startsScalar is true when a quote after prefix opens a quoted scalar, as
+// it does at the start of a line or after a key, list item or flow marker
+func startsScalar(prefix []byte) bool {
+	prefix = bytes.TrimRight(prefix, " \t")
+	return len(prefix) == 0 || bytes.IndexByte([]byte(":-[{,?"), prefix[len(prefix)-1]) > -1
+}
+
+// ReadVariablesFile reads KEY=VALUE lines, blank lines and lines starting
+// with # are skipped and values may be wrapped in matching quotes
+func ReadVariablesFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !variableName.MatchString(name) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_SubstituteVariables(t *testing.T) {
+	lookup := NewLookup(map[string]string{
+		"TAG":   "0.2.0",
+		"EMPTY": "",
+	})
+
+	var substituteTests = []struct {
+		title string
+		input string
+		want  string
+	}{
+		{title: "No references", input: "image: figlet:latest", want: "image: figlet:latest"},
+		{title: "Variable", input: "image: figlet:${TAG}", want: "image: figlet:0.2.0"},
+		{title: "Unused default", input: "image: figlet:${TAG:-latest}", want: "image: figlet:0.2.0"},
+		{title: "Default", input: "image: figlet:${OTHER_TAG:-latest}", want: "image: figlet:latest"},
+		{title: "Empty value", input: "image: figlet${EMPTY}", want: "image: figlet"},
+		{title: "Escaped reference", input: "fprocess: echo $${TAG}", want: "fprocess: echo ${TAG}"},
+		{title: "Plain dollar", input: "fprocess: echo $TAG $", want: "fprocess: echo $TAG $"},
+		{title: "Commented out line", input: "# image: figlet:${OLD_TAG}\nimage: figlet:${TAG}", want: "# image: figlet:${OLD_TAG}\nimage: figlet:0.2.0"},
+		{title: "Trailing comment", input: "image: figlet:${TAG} # was ${OLD_TAG}", want: "image: figlet:0.2.0 # was ${OLD_TAG}"},
+		{title: "Hash in a value", input: "fprocess: echo#${TAG}", want: "fprocess: echo#0.2.0"},
+		{title: "Hash in quotes", input: "fprocess: \"echo # ${TAG}\" # ${OLD_TAG}", want: "fprocess: \"echo # 0.2.0\" # ${OLD_TAG}"},
+		{title: "Apostrophe in a value", input: "description: it's ${TAG} # ${OLD_TAG}", want: "description: it's 0.2.0 # ${OLD_TAG}"},
+	}
+
+	for _, test := range substituteTests {
+		t.Run(test.title, func(t *testing.T) {
+			out, err := SubstituteVariables([]byte(test.input), "stack.yml", lookup)
+			if err != nil {
+				t.Fatalf("Error returned: %s", err)
+			}
+			if string(out) != test.want {
+				t.Fatalf("want %q, got %q", test.want, string(out))
+			}
+		})
+	}
+}
+
+func Test_SubstituteVariables_EnvironmentWins(t *testing.T) {
+	os.Setenv("FAAS_CLI_TEST_TAG", "from-env")
+	defer os.Unsetenv("FAAS_CLI_TEST_TAG")
+
+	lookup := NewLookup(map[string]string{"FAAS_CLI_TEST_TAG": "from-file"})
+	out, err := SubstituteVariables([]byte("${FAAS_CLI_TEST_TAG}"), "stack.yml", lookup)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if string(out) != "from-env" {
+		t.Fatalf("want the environment value, got %q", string(out))
+	}
+}
+
+func Test_SubstituteVariables_Errors(t *testing.T) {
+	input := `functions:
+  figlet:
+    image: figlet:${TAG}
+    fprocess: ${1BAD}
+    handler: ${HANDLER
+`
+
+	_, err := SubstituteVariables([]byte(input), "stack.yml", NewLookup(nil))
+	if err == nil {
+		t.Fatalf("want an error for undefined variables")
+	}
+
+	for _, want := range []string{
+		"stack.yml:3: variable TAG is not set and has no default, use ${TAG:-default} to give one",
+		"stack.yml:4: invalid variable reference ${1BAD}",
+		"stack.yml:5: unterminated variable reference",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("want %q in error, got:\n%s", want, err)
+		}
+	}
+}
+
+func Test_ReadVariablesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-variables")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "prod.env")
+	contents := `# production values
+TAG=0.2.0
+
+REGISTRY = "registry.example.com"
+GREETING='hello world'
+`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	values, err := ReadVariablesFile(path)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	want := map[string]string{
+		"TAG":      "0.2.0",
+		"REGISTRY": "registry.example.com",
+		"GREETING": "hello world",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("want %v, got %v", want, values)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("TAG=1\nnot a variable\n"), 0600); err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if _, err := ReadVariablesFile(path); err == nil || !strings.Contains(err.Error(), "prod.env:2: expected KEY=VALUE") {
+		t.Fatalf("want a line-level error, got: %v", err)
+	}
+}
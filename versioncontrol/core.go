@@ -34,6 +34,19 @@ func (v *vcsCmd) Invoke(dir string, args map[string]string) error {
 	return nil
 }
 
+// Output executes the vcsCmd like Invoke and returns the trimmed output of
+// the last command.
+func (v *vcsCmd) Output(dir string, args map[string]string) (string, error) {
+	var out []byte
+	for _, cmd := range v.cmds {
+		var err error
+		if out, err = v.run(dir, cmd, args, false); err != nil {
+			return "", fmt.Errorf("%s %s: %s", v.cmd, replaceVars(args, cmd), strings.TrimSpace(string(out)))
+		}
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // run is the generalized implementation of executing our commands.
 func (v *vcsCmd) run(dir string, cmdline string, keyval map[string]string, verbose bool) ([]byte, error) {
 	args := strings.Fields(cmdline)
@@ -52,18 +65,18 @@ func (v *vcsCmd) run(dir string, cmdline string, keyval map[string]string, verbo
 	cmd.Dir = dir
 	cmd.Env = envWithPWD(cmd.Dir)
 
-	var buf bytes.Buffer
+	var buf, errBuf bytes.Buffer
 	cmd.Stdout = &buf
-	cmd.Stderr = &buf
+	cmd.Stderr = &errBuf
 	err = cmd.Run()
-	out := buf.Bytes()
 	if err != nil {
+		out := append(buf.Bytes(), errBuf.Bytes()...)
 		if verbose {
 			os.Stderr.Write(out)
 		}
 		return out, err
 	}
-	return out, nil
+	return buf.Bytes(), nil
 }
 
 // replaceVars rewrites a string to replace variables written as {k}
@@ -4,7 +4,44 @@ package versioncontrol
 var GitClone = &vcsCmd{
 	name:   "Git",
 	cmd:    "git",
-	cmds:   []string{"clone {repo} {dir} --depth=1"},
+	cmds:   []string{"clone --depth=1 -- {repo} {dir}"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitCloneFull clones the whole history of a repo into a directory, so any
+// tag, branch or commit can be checked out
+var GitCloneFull = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"clone -- {repo} {dir}"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitInit creates an empty repository in a directory
+var GitInit = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"init --quiet -- {dir}"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitFetchRef fetches only the commit of a tag, branch or full commit SHA of
+// a repo into the repository of the directory and checks it out
+var GitFetchRef = &vcsCmd{
+	name: "Git",
+	cmd:  "git",
+	cmds: []string{
+		"fetch --quiet --depth=1 -- {repo} {ref}",
+		"checkout --quiet FETCH_HEAD --",
+	},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitCheckout checks out a tag, branch or commit in the repository of the directory
+var GitCheckout = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"checkout --quiet {ref} --"},
 	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
 }
 
@@ -21,3 +58,44 @@ var GitInitRepo = &vcsCmd{
 	},
 	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
 }
+
+// GitShortSHA prints the short commit of HEAD in the repository of the directory
+var GitShortSHA = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"rev-parse --short HEAD"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitLogSHA prints the short commit which last changed the directory
+var GitLogSHA = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"log -1 --format=%h -- ."},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitHeadSHA prints the full commit of HEAD in the repository of the directory
+var GitHeadSHA = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"rev-parse HEAD"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitBranch prints the branch checked out in the repository of the directory
+var GitBranch = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"rev-parse --abbrev-ref HEAD"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}
+
+// GitDescribe prints the closest tag of HEAD with the commits since, or the
+// short commit when there are no tags
+var GitDescribe = &vcsCmd{
+	name:   "Git",
+	cmd:    "git",
+	cmds:   []string{"describe --tags --always"},
+	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
+}